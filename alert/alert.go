@@ -1,17 +1,20 @@
 package alert
 
 import (
+	"bytes"
+	"context"
 	"embed"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"runtime"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/cameroncuttingedge/terminal-timer/random"
 	"github.com/cameroncuttingedge/terminal-timer/util"
 	"github.com/gen2brain/beeep"
 )
@@ -22,107 +25,151 @@ var wavFS embed.FS
 //go:embed icon/clock.png
 var clockPNG embed.FS
 
-func EndOfTimer(soundFilePath, title, message string) {
-	// Play the end of timer sound in a non-blocking way
-	go func() {
-		tmpFileName, err := PrepareSoundFile(soundFilePath)
-		if err != nil {
-			log.Printf("Error preparing sound file: %v", err)
-			return
-		}
+// UserSoundDir holds sounds added by the user. Names found here take
+// precedence over the embedded sounds.
+var UserSoundDir string
 
-		// This doesn't always work as someimes the applciation quits beforehand
-		// See the cleanup func in main.go for the backup plan
-		defer func() {
-			if removeErr := os.Remove(tmpFileName); removeErr != nil {
-				log.Printf("Error removing temporary file '%s': %v", tmpFileName, removeErr)
-			}
-		}()
+// Alarm repetition, set from the config file. With Repeat on, the sound is
+// played again after each RepeatGap until it is acknowledged or RepeatMax has
+// passed.
+var (
+	Repeat    bool
+	RepeatGap = 2 * time.Second
+	RepeatMax = 5 * time.Minute
+)
 
-		// Play the sound
-		err = ExecuteSoundPlayback(tmpFileName)
-		if err != nil {
-			log.Printf("Error playing sound: %v", err)
-		}
-	}()
+// EndOfTimer starts the alarm sound and notification on the active channels
+// without blocking. The returned acknowledge func silences the alarm and waits
+// for the player to exit; it is safe to call more than once.
+func EndOfTimer(soundFilePath string, note Notification) (acknowledge func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
 
-	// Execute notification display in a separate goroutine
+	// Drop any click left over from an earlier notification.
+	select {
+	case <-notificationActions:
+	default:
+	}
+
+	// Play the end of timer sound in a non-blocking way
 	go func() {
-		err := ShowNotification(title, message)
-		if err != nil {
-			log.Printf("Error showing notification: %v", err)
-		}
+		defer close(done)
+		// Players that can't read the sound from stdin get a temporary file,
+		// which util.Cleanup removes if the application quits mid-play
+		playAlarm(ctx, soundFilePath, note)
 	}()
+
+	// Execute notification display in a separate goroutine
+	go announce(note)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
 }
 
-func ExecuteSoundPlayback(tmpFileName string) error {
-	var cmd *exec.Cmd
+// Sound is a sound to play and how loud to play it.
+type Sound struct {
+	Name string
+	// Volume runs from 0 to 100.
+	Volume int
+	// FadeIn ramps the sound up from silence over this long.
+	FadeIn time.Duration
+}
 
-	switch runtime.GOOS {
-	case "darwin":
-		if util.CmdExists("afplay") {
-			cmd = exec.Command("afplay", tmpFileName)
-		} else {
-			return errors.New("no compatible media player found")
-		}
-	case "linux":
-		if util.CmdExists("ffplay") {
-			cmd = exec.Command("ffplay", "-nodisp", "-autoexit", tmpFileName)
-		} else if util.CmdExists("mpg123") {
-			cmd = exec.Command("mpg123", tmpFileName)
-		} else if util.CmdExists("paplay") {
-			cmd = exec.Command("paplay", tmpFileName)
-		} else if util.CmdExists("aplay") {
-			cmd = exec.Command("aplay", tmpFileName)
-		} else {
-			return errors.New("no compatible media player found")
-		}
-	case "windows":
-		if util.CmdExists("powershell") {
-			cmdStr := `$player = New-Object System.Media.SoundPlayer;` +
-				`$player.SoundLocation = '` + tmpFileName + `';` +
-				`$player.PlaySync();`
-			cmd = exec.Command("powershell", "-Command", cmdStr)
-		} else {
-			return errors.New("no compatible media player found")
-		}
-	default:
-		return errors.New("unsupported platform")
+// Default level applied by NewSound, set from the config file.
+var (
+	Volume = 100
+	FadeIn time.Duration
+)
+
+// NewSound returns the named sound at the configured volume and fade-in.
+func NewSound(name string) Sound {
+	return Sound{Name: name, Volume: Volume, FadeIn: FadeIn}
+}
+
+// unshaped reports whether the sound plays exactly as stored.
+func (s Sound) unshaped() bool {
+	return s.Volume >= 100 && s.FadeIn <= 0
+}
+
+// loadAudio decodes the sound and applies its volume and fade-in.
+func loadAudio(sound Sound) (*Audio, error) {
+	soundFile, err := openSound(sound.Name)
+	if err != nil {
+		return nil, err
 	}
+	defer soundFile.Close()
 
-	return cmd.Run()
+	audio, err := DecodeWAV(soundFile)
+	if err != nil {
+		return nil, err
+	}
+	if sound.unshaped() {
+		return audio, nil
+	}
+	return audio.WithLevel(sound.Volume, sound.FadeIn), nil
 }
 
-func PrepareSoundFile(filePath string) (string, error) {
-	soundFilePath := "WAV/" + filePath
+// soundReader returns the sound as WAV bytes. The file is streamed as is unless
+// its level must change.
+func soundReader(sound Sound) (io.ReadCloser, error) {
+	if sound.unshaped() {
+		return openSound(sound.Name)
+	}
+	audio, err := loadAudio(sound)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := EncodeWAV(&buf, audio); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(&buf), nil
+}
 
-	soundFile, err := wavFS.Open(soundFilePath)
+// PrepareSoundFile writes the sound to a new temporary WAV file for an
+// external player. The original file is copied as is unless its level must
+// change. release removes the file once the player is done with it.
+func PrepareSoundFile(sound Sound) (path string, release func(), err error) {
+	soundFile, err := openSound(sound.Name)
 	if err != nil {
-		log.Printf("Error opening embedded sound file '%s': %v", soundFilePath, err)
-		return "", errors.New("failed to open embedded sound file")
+		log.Printf("Error opening sound file '%s': %v", sound.Name, err)
+		return "", nil, errors.New("failed to open sound file")
 	}
 	defer soundFile.Close()
-	tmpFile, err := os.OpenFile(random.TempFileName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	tmpFile, release, err := util.CreateTemp("sound-*.wav")
 	if err != nil {
 		log.Printf("Error creating temporary file for sound: %v", err)
-		return "", errors.New("failed to create temporary file for sound")
+		return "", nil, errors.New("failed to create temporary file for sound")
 	}
 	tmpFileName := tmpFile.Name()
 
-	if _, err = io.Copy(tmpFile, soundFile); err != nil {
+	if sound.unshaped() {
+		_, err = io.Copy(tmpFile, soundFile)
+	} else {
+		var audio *Audio
+		if audio, err = loadAudio(sound); err == nil {
+			err = EncodeWAV(tmpFile, audio)
+		}
+	}
+	if err != nil {
 		tmpFile.Close()
-		os.Remove(tmpFileName) // Clean up even in case of error
+		release() // Clean up even in case of error
 		log.Printf("Error copying sound file to temporary file '%s': %v", tmpFileName, err)
-		return "", errors.New("failed to copy sound file to temporary file")
+		return "", nil, errors.New("failed to copy sound file to temporary file")
 	}
 
 	if err := tmpFile.Close(); err != nil {
-		os.Remove(tmpFileName) // Clean up even in case of error
+		release() // Clean up even in case of error
 		log.Printf("Error closing temporary sound file '%s': %v", tmpFileName, err)
-		return "", errors.New("failed to close temporary sound file")
+		return "", nil, errors.New("failed to close temporary sound file")
 	}
 
-	return tmpFileName, nil
+	return tmpFileName, release, nil
 }
 
 func ShowNotification(title, message string) error {
@@ -134,13 +181,13 @@ func ShowNotification(title, message string) error {
 	defer clockFile.Close()
 
 	// Create a temporary file for the image
-	tmpFile, err := os.CreateTemp("", "clock-*.png")
+	tmpFile, release, err := util.CreateTemp("clock-*.png")
 	if err != nil {
 		log.Printf("Error creating temporary file for image: %v", err)
 		return err
 	}
+	defer release() // Clean up the temp file after use
 	defer tmpFile.Close()
-	defer os.Remove(tmpFile.Name()) // Clean up the temp file after use
 
 	// Copy the clock image content to the temporary file
 	_, err = io.Copy(tmpFile, clockFile)
@@ -159,6 +206,62 @@ func ShowNotification(title, message string) error {
 	return nil
 }
 
+// openSound resolves a sound by absolute path, then in UserSoundDir, then in
+// the embedded set.
+func openSound(name string) (io.ReadCloser, error) {
+	if filepath.IsAbs(name) {
+		return os.Open(name)
+	}
+	if UserSoundDir != "" {
+		file, err := os.Open(filepath.Join(UserSoundDir, filepath.Base(name)))
+		if err == nil {
+			return file, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	file, err := wavFS.Open("WAV/" + name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no sound named %q among the built-in or user sounds", name)
+	}
+	return file, err
+}
+
+// ValidateSound checks that name resolves to a WAV file that can be decoded.
+func ValidateSound(name string) error {
+	soundFile, err := openSound(name)
+	if err != nil {
+		return err
+	}
+	defer soundFile.Close()
+
+	_, err = ReadWAVHeader(soundFile)
+	return err
+}
+
+// ListUserSounds lists the WAV files in UserSoundDir.
+func ListUserSounds() ([]string, error) {
+	if UserSoundDir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(UserSoundDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sounds []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".wav") {
+			sounds = append(sounds, entry.Name())
+		}
+	}
+	return sounds, nil
+}
+
 func ListValidSounds() ([]string, error) {
 	var sounds []string
 
@@ -0,0 +1,209 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Raw ALSA playback through the kernel PCM interface, for machines without a
+// sound server. Only plain hardware devices are used, so the audio is
+// converted to a rate and channel count the card accepts.
+
+const (
+	alsaAccessRWInterleaved = 3
+	alsaFormatS16LE         = 2
+	alsaSubformatStd        = 0
+
+	alsaParamAccess    = 0
+	alsaParamFormat    = 1
+	alsaParamSubformat = 2
+	alsaParamChannels  = 10
+	alsaParamRate      = 11
+	alsaFirstInterval  = 8
+
+	alsaIntervalInteger = 1 << 2
+	alsaChunkFrames     = 4096
+)
+
+type alsaMask struct {
+	bits [8]uint32
+}
+
+type alsaInterval struct {
+	min, max, flags uint32
+}
+
+// alsaHwParams mirrors struct snd_pcm_hw_params.
+type alsaHwParams struct {
+	flags     uint32
+	masks     [3]alsaMask
+	mres      [5]alsaMask
+	intervals [12]alsaInterval
+	ires      [9]alsaInterval
+	rmask     uint32
+	cmask     uint32
+	info      uint32
+	msbits    uint32
+	rateNum   uint32
+	rateDen   uint32
+	fifoSize  uint
+	reserved  [64]byte
+}
+
+// alsaXferi mirrors struct snd_xferi.
+type alsaXferi struct {
+	result int
+	buf    uintptr
+	frames uint
+}
+
+func alsaIoctlNumber(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'A'<<8 | nr
+}
+
+var (
+	alsaIoctlHwParams    = alsaIoctlNumber(3, 0x11, unsafe.Sizeof(alsaHwParams{}))
+	alsaIoctlPrepare     = alsaIoctlNumber(0, 0x40, 0)
+	alsaIoctlDrop        = alsaIoctlNumber(0, 0x43, 0)
+	alsaIoctlDrain       = alsaIoctlNumber(0, 0x44, 0)
+	alsaIoctlWriteFrames = alsaIoctlNumber(1, 0x50, unsafe.Sizeof(alsaXferi{}))
+)
+
+func alsaAvailable() error {
+	if devices, _ := filepath.Glob("/dev/snd/pcmC*D*p"); len(devices) == 0 {
+		return errors.New("no ALSA playback devices")
+	}
+	return nil
+}
+
+func playALSA(ctx context.Context, audio *Audio) error {
+	devices, _ := filepath.Glob("/dev/snd/pcmC*D*p")
+	if len(devices) == 0 {
+		return errors.New("no ALSA playback devices")
+	}
+
+	var lastErr error
+	for _, device := range devices {
+		fd, err := syscall.Open(device, syscall.O_WRONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", device, err)
+			continue
+		}
+		err = alsaPlayOn(ctx, fd, audio)
+		syscall.Close(fd)
+		if err == nil {
+			return nil
+		}
+		lastErr = fmt.Errorf("%s: %w", device, err)
+	}
+	return lastErr
+}
+
+func alsaPlayOn(ctx context.Context, fd int, audio *Audio) error {
+	// Opened non-blocking so a busy device fails instead of waiting; writes
+	// should block.
+	if err := syscall.SetNonblock(fd, false); err != nil {
+		return err
+	}
+
+	converted, err := alsaConfigure(fd, audio)
+	if err != nil {
+		return err
+	}
+	if err := alsaIoctl(fd, alsaIoctlPrepare, nil); err != nil {
+		return err
+	}
+
+	samples := converted.Samples
+	channels := converted.Channels
+	for len(samples) > 0 {
+		if ctx.Err() != nil {
+			alsaIoctl(fd, alsaIoctlDrop, nil)
+			return nil
+		}
+		frames := len(samples) / channels
+		if frames > alsaChunkFrames {
+			frames = alsaChunkFrames
+		}
+		xfer := alsaXferi{buf: uintptr(unsafe.Pointer(&samples[0])), frames: uint(frames)}
+		err := alsaIoctl(fd, alsaIoctlWriteFrames, unsafe.Pointer(&xfer))
+		runtime.KeepAlive(samples)
+		if err == syscall.EPIPE {
+			// Underrun: re-arm the device and resend.
+			if err := alsaIoctl(fd, alsaIoctlPrepare, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		samples = samples[int(xfer.result)*channels:]
+	}
+	return alsaIoctl(fd, alsaIoctlDrain, nil)
+}
+
+// alsaConfigure sets the hardware parameters, trying the sound's own format
+// first and then common rates, and returns the audio converted to match.
+func alsaConfigure(fd int, audio *Audio) (*Audio, error) {
+	candidates := [][2]int{
+		{audio.SampleRate, audio.Channels},
+		{audio.SampleRate, 2},
+		{48000, 2},
+		{44100, 2},
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		params := alsaAnyParams()
+		params.masks[alsaParamAccess] = alsaSingleBit(alsaAccessRWInterleaved)
+		params.masks[alsaParamFormat] = alsaSingleBit(alsaFormatS16LE)
+		params.masks[alsaParamSubformat] = alsaSingleBit(alsaSubformatStd)
+		params.intervals[alsaParamRate-alsaFirstInterval] = alsaExact(candidate[0])
+		params.intervals[alsaParamChannels-alsaFirstInterval] = alsaExact(candidate[1])
+
+		lastErr = alsaIoctl(fd, alsaIoctlHwParams, unsafe.Pointer(params))
+		if lastErr == nil {
+			return audio.Convert(candidate[0], candidate[1]), nil
+		}
+	}
+	return nil, fmt.Errorf("no supported format: %w", lastErr)
+}
+
+// alsaAnyParams returns parameters that allow every configuration, like
+// snd_pcm_hw_params_any.
+func alsaAnyParams() *alsaHwParams {
+	params := &alsaHwParams{rmask: ^uint32(0), info: ^uint32(0)}
+	for i := range params.masks {
+		for j := range params.masks[i].bits {
+			params.masks[i].bits[j] = ^uint32(0)
+		}
+	}
+	for i := range params.intervals {
+		params.intervals[i] = alsaInterval{min: 0, max: ^uint32(0)}
+	}
+	return params
+}
+
+func alsaSingleBit(bit uint) alsaMask {
+	var mask alsaMask
+	mask.bits[bit/32] = 1 << (bit % 32)
+	return mask
+}
+
+func alsaExact(value int) alsaInterval {
+	return alsaInterval{min: uint32(value), max: uint32(value), flags: alsaIntervalInteger}
+}
+
+func alsaIoctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
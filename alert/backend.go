@@ -0,0 +1,285 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// AudioBackend is one way of playing a sound.
+type AudioBackend interface {
+	// Name is what the audio config key uses to select the backend.
+	Name() string
+	// Available reports why the backend cannot be used here, or nil if it can.
+	Available() error
+	// Play plays the sound, blocking until it finishes or ctx is cancelled,
+	// which must stop it.
+	Play(ctx context.Context, sound Sound) error
+}
+
+// Special values of the audio config key alongside the backend names.
+const (
+	AudioAuto     = "auto"
+	AudioExternal = "external"
+)
+
+// AudioMode names the backend to play sounds with. AudioAuto tries every
+// automatic backend in registration order and AudioExternal does the same
+// but skips the in-process one.
+var AudioMode = AudioAuto
+
+type registration struct {
+	backend AudioBackend
+	auto    bool
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []registration
+)
+
+// RegisterBackend makes a backend selectable by name. Automatic backends are
+// also tried, in registration order, when no backend is chosen explicitly.
+func RegisterBackend(backend AudioBackend, auto bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, registration{backend, auto})
+}
+
+// Backends returns every registered backend in registration order.
+func Backends() []AudioBackend {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	backends := make([]AudioBackend, len(registry))
+	for i, r := range registry {
+		backends[i] = r.backend
+	}
+	return backends
+}
+
+// LookupBackend finds a registered backend by name.
+func LookupBackend(name string) (AudioBackend, bool) {
+	for _, backend := range Backends() {
+		if backend.Name() == name {
+			return backend, true
+		}
+	}
+	return nil, false
+}
+
+// ValidAudioMode reports whether mode can be used as AudioMode.
+func ValidAudioMode(mode string) bool {
+	if mode == AudioAuto || mode == AudioExternal {
+		return true
+	}
+	_, ok := LookupBackend(mode)
+	return ok
+}
+
+// candidateBackends returns the backends AudioMode allows, in the order they
+// should be tried.
+func candidateBackends() []AudioBackend {
+	if backend, ok := LookupBackend(AudioMode); ok {
+		return []AudioBackend{backend}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	var backends []AudioBackend
+	for _, r := range registry {
+		if !r.auto || (AudioMode == AudioExternal && r.backend.Name() == nativeBackendName) {
+			continue
+		}
+		backends = append(backends, r.backend)
+	}
+	return backends
+}
+
+// SelectedBackend returns the backend PlaySound would use first.
+func SelectedBackend() (AudioBackend, error) {
+	var failures []string
+	for _, backend := range candidateBackends() {
+		err := backend.Available()
+		if err == nil {
+			return backend, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", backend.Name(), err))
+	}
+	if len(failures) == 0 {
+		return nil, errors.New("no audio backends registered")
+	}
+	return nil, errors.New(strings.Join(failures, "; "))
+}
+
+// PlaySound plays a sound by name at the configured level. See Play.
+func PlaySound(ctx context.Context, name string) error {
+	return Play(ctx, NewSound(name))
+}
+
+// Play plays a sound with the configured backend, blocking until it finishes
+// or ctx is cancelled. When no backend is chosen explicitly, each available
+// backend is tried in turn until one succeeds.
+func Play(ctx context.Context, sound Sound) error {
+	var failures []string
+	for _, backend := range candidateBackends() {
+		if err := backend.Available(); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", backend.Name(), err))
+			continue
+		}
+		err := backend.Play(ctx, sound)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", backend.Name(), err))
+	}
+	if len(failures) == 0 {
+		return errors.New("no compatible media player found")
+	}
+	return fmt.Errorf("no audio backend could play %s: %s", sound.Name, strings.Join(failures, "; "))
+}
+
+// playerBackend runs an external media player. Players that can read the
+// sound from stdin get it piped in; the others play a temporary copy.
+type playerBackend struct {
+	name    string
+	command string
+	goos    string
+	args    func(path string) []string
+	// stdin is set when the player reads a WAV from stdin given "-" as the
+	// path.
+	stdin bool
+}
+
+func (b playerBackend) Name() string {
+	return b.name
+}
+
+func (b playerBackend) Available() error {
+	if b.goos != "" && runtime.GOOS != b.goos {
+		return fmt.Errorf("only available on %s", b.goos)
+	}
+	if !util.CmdExists(b.command) {
+		return fmt.Errorf("%s not found in PATH", b.command)
+	}
+	return nil
+}
+
+func (b playerBackend) Play(ctx context.Context, sound Sound) error {
+	if b.stdin {
+		err := b.playStdin(ctx, sound)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("Piping %s to %s failed, falling back to a temporary file: %v", sound.Name, b.name, err)
+	}
+
+	tmpFileName, release, err := PrepareSoundFile(sound)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	err = exec.CommandContext(ctx, b.command, b.args(tmpFileName)...).Run()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// playStdin pipes the sound to the player without touching the disk.
+func (b playerBackend) playStdin(ctx context.Context, sound Sound) error {
+	wav, err := soundReader(sound)
+	if err != nil {
+		return err
+	}
+	defer wav.Close()
+
+	cmd := exec.CommandContext(ctx, b.command, b.args("-")...)
+	cmd.Stdin = wav
+	return cmd.Run()
+}
+
+// nullBackend plays nothing.
+type nullBackend struct{}
+
+func (nullBackend) Name() string {
+	return "none"
+}
+
+func (nullBackend) Available() error {
+	return nil
+}
+
+func (nullBackend) Play(ctx context.Context, sound Sound) error {
+	return nil
+}
+
+// RecordingBackend logs what would have played instead of making a sound,
+// and keeps a list of it for tests.
+type RecordingBackend struct {
+	mu     sync.Mutex
+	played []Sound
+}
+
+// Recorder is the registered RecordingBackend, selected with audio=record.
+var Recorder = &RecordingBackend{}
+
+func (r *RecordingBackend) Name() string {
+	return "record"
+}
+
+func (r *RecordingBackend) Available() error {
+	return nil
+}
+
+func (r *RecordingBackend) Play(ctx context.Context, sound Sound) error {
+	if err := ValidateSound(sound.Name); err != nil {
+		return err
+	}
+	log.Printf("Would have played sound '%s' at volume %d with %v fade-in", sound.Name, sound.Volume, sound.FadeIn)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.played = append(r.played, sound)
+	return nil
+}
+
+// Played returns the sounds recorded so far.
+func (r *RecordingBackend) Played() []Sound {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sound(nil), r.played...)
+}
+
+func init() {
+	RegisterBackend(nativeBackend{}, true)
+	RegisterBackend(playerBackend{name: "afplay", command: "afplay", goos: "darwin", args: func(path string) []string {
+		return []string{path}
+	}}, true)
+	RegisterBackend(playerBackend{name: "ffplay", command: "ffplay", args: func(path string) []string {
+		return []string{"-nodisp", "-autoexit", "-loglevel", "quiet", path}
+	}, stdin: true}, true)
+	RegisterBackend(playerBackend{name: "mpg123", command: "mpg123", args: func(path string) []string {
+		return []string{"-q", path}
+	}}, true)
+	RegisterBackend(playerBackend{name: "paplay", command: "paplay", args: func(path string) []string {
+		return []string{path}
+	}, stdin: true}, true)
+	RegisterBackend(playerBackend{name: "aplay", command: "aplay", args: func(path string) []string {
+		return []string{"-q", path}
+	}, stdin: true}, true)
+	RegisterBackend(playerBackend{name: "powershell", command: "powershell", goos: "windows", args: func(path string) []string {
+		return []string{"-Command", `$player = New-Object System.Media.SoundPlayer;` +
+			`$player.SoundLocation = '` + path + `';` +
+			`$player.PlaySync();`}
+	}}, true)
+	RegisterBackend(nullBackend{}, false)
+	RegisterBackend(Recorder, false)
+}
@@ -0,0 +1,120 @@
+package alert
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// record selects the recording backend with only the sound channel active and
+// restores the alarm settings when the test ends.
+func record(t *testing.T) {
+	t.Helper()
+	mode, active, volume, fadeIn := AudioMode, Active, Volume, FadeIn
+	repeat, repeatGap, repeatMax, escalation := Repeat, RepeatGap, RepeatMax, Escalation
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		AudioMode, Active, Volume, FadeIn = mode, active, volume, fadeIn
+		Repeat, RepeatGap, RepeatMax, Escalation = repeat, repeatGap, repeatMax, escalation
+		log.SetOutput(output)
+	})
+
+	AudioMode = "record"
+	Active = Channels{Sound: true}
+	Volume, FadeIn = 100, 0
+	Repeat, Escalation = false, nil
+
+	Recorder.mu.Lock()
+	Recorder.played = nil
+	Recorder.mu.Unlock()
+}
+
+func TestRecordingBackendPlaysOnce(t *testing.T) {
+	record(t)
+
+	playAlarm(context.Background(), "Beeper.wav", Notification{})
+
+	played := Recorder.Played()
+	if len(played) != 1 || played[0].Name != "Beeper.wav" || played[0].Volume != 100 {
+		t.Fatalf("played %+v, want Beeper.wav once at volume 100", played)
+	}
+}
+
+func TestRecordingBackendRepeatGap(t *testing.T) {
+	record(t)
+	Repeat = true
+	RepeatGap = 50 * time.Millisecond
+	RepeatMax = 275 * time.Millisecond
+
+	playAlarm(context.Background(), "Beeper.wav", Notification{})
+
+	// Plays can start no sooner than every RepeatGap, so at most one more
+	// than fits in RepeatMax.
+	played := Recorder.Played()
+	if len(played) < 2 || len(played) > 6 {
+		t.Fatalf("played %d times, want 2 to 6 with a %v gap over %v", len(played), RepeatGap, RepeatMax)
+	}
+}
+
+func TestRecordingBackendStopsWhenAcknowledged(t *testing.T) {
+	record(t)
+	Repeat = true
+	RepeatGap = 20 * time.Millisecond
+	RepeatMax = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		playAlarm(ctx, "Beeper.wav", Notification{})
+	}()
+	time.Sleep(70 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("alarm kept playing after it was acknowledged")
+	}
+
+	count := len(Recorder.Played())
+	time.Sleep(50 * time.Millisecond)
+	if after := len(Recorder.Played()); after != count || count == 0 {
+		t.Fatalf("played %d times before and %d after acknowledging, want more than zero and no change", count, after)
+	}
+}
+
+func TestRecordingBackendEscalation(t *testing.T) {
+	record(t)
+	RepeatGap = 25 * time.Millisecond
+	RepeatMax = 50 * time.Millisecond
+	steps, err := ParseEscalation("0s Polite.wav 40, 100ms Alarmed.wav 90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Escalation = steps
+
+	playAlarm(context.Background(), "Beeper.wav", Notification{})
+
+	played := Recorder.Played()
+	if len(played) < 2 {
+		t.Fatalf("played %+v, want the alarm to repeat through the steps", played)
+	}
+	if first := played[0]; first.Name != "Polite.wav" || first.Volume != 40 {
+		t.Errorf("first play was %+v, want the step at zero: Polite.wav at 40", first)
+	}
+	if last := played[len(played)-1]; last.Name != "Alarmed.wav" || last.Volume != 90 {
+		t.Errorf("last play was %+v, want the last step: Alarmed.wav at 90", last)
+	}
+	escalated := false
+	for _, sound := range played {
+		if sound.Name == "Alarmed.wav" {
+			escalated = true
+		} else if escalated {
+			t.Errorf("played %+v, want no return to an earlier step", played)
+			break
+		}
+	}
+}
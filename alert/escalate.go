@@ -0,0 +1,179 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EscalationStep changes how the alarm sounds once it has gone unacknowledged
+// for After. Unset fields keep the value of the step before it, and a step at
+// zero sets how the alarm starts.
+type EscalationStep struct {
+	After time.Duration
+	// Sound and Volume replace the alarm sound when set.
+	Sound  string
+	Volume int
+	// Bell rings and flashes the terminal on every repeat.
+	Bell bool
+	// Notify shows the desktop notification again on every repeat.
+	Notify bool
+}
+
+// Escalation is the list of steps applied to the alarm, set from the config
+// file and ordered by After.
+var Escalation []EscalationStep
+
+// ParseEscalation reads a comma separated list of steps such as
+// "1m Alarmed.wav 100, 5m bell notify". Each step starts with a duration and is
+// followed by any of a sound name, a volume from 0 to 100, bell and notify.
+func ParseEscalation(value string) ([]EscalationStep, error) {
+	var steps []EscalationStep
+	for _, field := range strings.Split(value, ",") {
+		words := strings.Fields(field)
+		if len(words) == 0 {
+			continue
+		}
+		after, err := time.ParseDuration(words[0])
+		if err != nil || after < 0 {
+			return nil, fmt.Errorf("escalation step %q: want it to start with a duration such as 1m", strings.TrimSpace(field))
+		}
+		step := EscalationStep{After: after, Volume: -1}
+		for _, word := range words[1:] {
+			switch lower := strings.ToLower(word); {
+			case lower == "bell":
+				step.Bell = true
+			case lower == "notify":
+				step.Notify = true
+			case isVolume(word):
+				step.Volume, _ = strconv.Atoi(strings.TrimSuffix(word, "%"))
+			default:
+				step.Sound = word
+			}
+		}
+		if err := step.Validate(); err != nil {
+			return nil, fmt.Errorf("escalation step %q: %v", strings.TrimSpace(field), err)
+		}
+		steps = append(steps, step)
+	}
+	SortEscalation(steps)
+	return steps, nil
+}
+
+// Validate checks the step has a delay of zero or more, a known sound and a
+// volume from 0 to 100, or -1 to keep the volume as it was.
+func (s EscalationStep) Validate() error {
+	if s.After < 0 {
+		return errors.New("want a delay of zero or more")
+	}
+	if s.Sound != "" {
+		if err := ValidateSound(s.Sound); err != nil {
+			return err
+		}
+	}
+	if s.Volume < -1 || s.Volume > 100 {
+		return fmt.Errorf("invalid volume %d: want a number from 0 to 100", s.Volume)
+	}
+	return nil
+}
+
+// SortEscalation puts steps in the order they apply.
+func SortEscalation(steps []EscalationStep) {
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].After < steps[j].After })
+}
+
+func isVolume(word string) bool {
+	volume, err := strconv.Atoi(strings.TrimSuffix(word, "%"))
+	return err == nil && volume >= 0 && volume <= 100
+}
+
+// alarmLevel is what the alarm does on each play, built up from the steps
+// reached so far.
+type alarmLevel struct {
+	sound  Sound
+	bell   bool
+	notify bool
+}
+
+func (l alarmLevel) apply(step EscalationStep) alarmLevel {
+	if step.Sound != "" {
+		l.sound.Name = step.Sound
+	}
+	if step.Volume >= 0 {
+		l.sound.Volume = step.Volume
+	}
+	l.bell = l.bell || step.Bell
+	l.notify = l.notify || step.Notify
+	return l
+}
+
+// playAlarm sounds the alarm on the active channels until ctx is cancelled.
+// Without Repeat or Escalation it sounds once; escalation always repeats.
+// RepeatMax counts from the last escalation step.
+func playAlarm(ctx context.Context, name string, note Notification) {
+	steps := Escalation
+	repeat := Repeat || len(steps) > 0
+
+	start := time.Now()
+	if repeat && RepeatMax > 0 {
+		limit := RepeatMax
+		if len(steps) > 0 {
+			limit += steps[len(steps)-1].After
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+
+	level := alarmLevel{sound: NewSound(name)}
+	note.Urgency = UrgencyCritical
+	for first := true; ; first = false {
+		for len(steps) > 0 && time.Since(start) >= steps[0].After {
+			level = level.apply(steps[0])
+			steps = steps[1:]
+		}
+
+		if level.bell || Active.Terminal {
+			ringBell()
+		}
+		if level.notify && !first {
+			go announce(note)
+		}
+		if Active.Sound {
+			if err := Play(ctx, level.sound); err != nil {
+				log.Printf("Error playing sound: %v", err)
+				// Keep going while something else can still get the user's
+				// attention, such as speaking the reminder.
+				if len(steps) == 0 && !level.bell && !level.notify && !Active.Terminal && !(Active.Speech && first) {
+					return
+				}
+			}
+		}
+		if Active.Speech && first {
+			if err := Speak(ctx, note.Message); err != nil && ctx.Err() == nil {
+				log.Printf("Error speaking reminder: %v", err)
+			}
+		}
+		if !repeat {
+			return
+		}
+
+		// Wait for the next repeat, cut short if a step is due sooner.
+		wait := RepeatGap
+		if len(steps) > 0 {
+			if untilStep := steps[0].After - time.Since(start); untilStep < wait {
+				wait = untilStep
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const nativeBackendName = "native"
+
+// nativeOutput is an in-process way of getting samples to the sound card.
+type nativeOutput struct {
+	name      string
+	available func() error
+	play      func(ctx context.Context, audio *Audio) error
+}
+
+var errNoNativeOutput = errors.New("no in-process audio output on this platform")
+
+// nativeBackend decodes sounds itself and plays them through the first
+// native output that accepts them, so no media player is needed.
+type nativeBackend struct{}
+
+func (nativeBackend) Name() string {
+	return nativeBackendName
+}
+
+func (nativeBackend) Available() error {
+	if len(nativeOutputs) == 0 {
+		return errNoNativeOutput
+	}
+	var failures []string
+	for _, output := range nativeOutputs {
+		err := output.available()
+		if err == nil {
+			return nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", output.name, err))
+	}
+	return errors.New(strings.Join(failures, "; "))
+}
+
+func (nativeBackend) Play(ctx context.Context, sound Sound) error {
+	audio, err := loadAudio(sound)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, output := range nativeOutputs {
+		if output.available() != nil {
+			continue
+		}
+		err := output.play(ctx, audio)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", output.name, err))
+	}
+	if len(failures) == 0 {
+		return errNoNativeOutput
+	}
+	return errors.New(strings.Join(failures, "; "))
+}
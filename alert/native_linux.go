@@ -0,0 +1,6 @@
+package alert
+
+var nativeOutputs = []nativeOutput{
+	{"pulseaudio", pulseAvailable, playPulse},
+	{"alsa", alsaAvailable, playALSA},
+}
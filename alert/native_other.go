@@ -0,0 +1,5 @@
+//go:build !linux
+
+package alert
+
+var nativeOutputs []nativeOutput
@@ -0,0 +1,68 @@
+package alert
+
+import (
+	"errors"
+	"log"
+
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// Urgency is how insistent a desktop notification is.
+type Urgency byte
+
+const (
+	UrgencyLow Urgency = iota
+	UrgencyNormal
+	UrgencyCritical
+)
+
+// Action is a button on a notification. Key is delivered on
+// NotificationActions when the button is clicked.
+type Action struct {
+	Key   string
+	Label string
+}
+
+// Notification is a desktop notification. Urgency and Actions are only
+// honoured where the desktop supports them.
+type Notification struct {
+	Title   string
+	Message string
+	Urgency Urgency
+	Actions []Action
+}
+
+// errNoDesktopNotifications is returned where there is no desktop
+// notification service to talk to directly.
+var errNoDesktopNotifications = errors.New("desktop notifications over D-Bus are only supported on Linux")
+
+var notificationActions = make(chan string, 1)
+
+// NotificationActions delivers the key of each notification action the user
+// clicks.
+func NotificationActions() <-chan string {
+	return notificationActions
+}
+
+// actionInvoked passes a clicked action on, dropping it if the last one has
+// not been handled yet.
+func actionInvoked(key string) {
+	select {
+	case notificationActions <- key:
+	default:
+	}
+}
+
+// Notify shows a notification, talking to the desktop directly where it can
+// and falling back to ShowNotification otherwise. Each notification replaces
+// the one before it rather than stacking up.
+func Notify(n Notification) error {
+	err := notifyDesktop(n)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errNoDesktopNotifications) && util.Logging {
+		log.Printf("Falling back to basic notifications: %v", err)
+	}
+	return ShowNotification(n.Title, n.Message)
+}
@@ -0,0 +1,146 @@
+package alert
+
+import (
+	"image"
+	"image/draw"
+	"image/png"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsName  = "org.freedesktop.Notifications"
+	notificationsPath  = dbus.ObjectPath("/org/freedesktop/Notifications")
+	notificationsAppID = "terminal-timer"
+)
+
+// desktop is the session bus connection used for notifications, opened on
+// first use. lastID is the notification the next one replaces.
+var desktop struct {
+	once   sync.Once
+	conn   *dbus.Conn
+	err    error
+	mu     sync.Mutex
+	lastID uint32
+}
+
+// imageData is the image-data hint, an icon sent inline so no file is needed.
+type imageData struct {
+	Width, Height, RowStride int32
+	HasAlpha                 bool
+	BitsPerSample, Channels  int32
+	Data                     []byte
+}
+
+func notifyDesktop(n Notification) error {
+	conn, err := desktopConn()
+	if err != nil {
+		return err
+	}
+
+	actions := make([]string, 0, 2*len(n.Actions))
+	for _, action := range n.Actions {
+		actions = append(actions, action.Key, action.Label)
+	}
+	hints := map[string]dbus.Variant{
+		"urgency":       dbus.MakeVariant(byte(n.Urgency)),
+		"desktop-entry": dbus.MakeVariant(notificationsAppID),
+	}
+	if icon, err := clockImage(); err == nil {
+		hints["image-data"] = dbus.MakeVariant(icon)
+	}
+	timeout := int32(-1)
+	if n.Urgency == UrgencyCritical {
+		// Stay up until dismissed.
+		timeout = 0
+	}
+
+	desktop.mu.Lock()
+	defer desktop.mu.Unlock()
+	var id uint32
+	err = conn.Object(notificationsName, notificationsPath).Call(notificationsName+".Notify", 0,
+		notificationsAppID, desktop.lastID, "", n.Title, n.Message, actions, hints, timeout).Store(&id)
+	if err != nil {
+		return err
+	}
+	desktop.lastID = id
+	return nil
+}
+
+// desktopConn connects to the session bus and starts passing on clicked
+// actions for our notifications.
+func desktopConn() (*dbus.Conn, error) {
+	desktop.once.Do(func() {
+		conn, err := dbus.SessionBus()
+		if err != nil {
+			desktop.err = err
+			return
+		}
+		err = conn.AddMatchSignal(
+			dbus.WithMatchObjectPath(notificationsPath),
+			dbus.WithMatchInterface(notificationsName),
+			dbus.WithMatchMember("ActionInvoked"),
+		)
+		if err != nil {
+			desktop.err = err
+			return
+		}
+		signals := make(chan *dbus.Signal, 4)
+		conn.Signal(signals)
+		go func() {
+			for signal := range signals {
+				var id uint32
+				var key string
+				if signal.Name != notificationsName+".ActionInvoked" || dbus.Store(signal.Body, &id, &key) != nil {
+					continue
+				}
+				desktop.mu.Lock()
+				ours := id == desktop.lastID
+				desktop.mu.Unlock()
+				if ours {
+					actionInvoked(key)
+				}
+			}
+		}()
+		desktop.conn = conn
+	})
+	return desktop.conn, desktop.err
+}
+
+var clockIcon struct {
+	once sync.Once
+	data imageData
+	err  error
+}
+
+// clockImage decodes the embedded clock icon into the image-data layout.
+func clockImage() (imageData, error) {
+	clockIcon.once.Do(func() {
+		file, err := clockPNG.Open("icon/clock.png")
+		if err != nil {
+			clockIcon.err = err
+			return
+		}
+		defer file.Close()
+
+		img, err := png.Decode(file)
+		if err != nil {
+			clockIcon.err = err
+			return
+		}
+		bounds := img.Bounds()
+		rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+		clockIcon.data = imageData{
+			Width:         int32(bounds.Dx()),
+			Height:        int32(bounds.Dy()),
+			RowStride:     int32(rgba.Stride),
+			HasAlpha:      true,
+			BitsPerSample: 8,
+			Channels:      4,
+			Data:          rgba.Pix,
+		}
+	})
+	return clockIcon.data, clockIcon.err
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package alert
+
+func notifyDesktop(n Notification) error {
+	return errNoDesktopNotifications
+}
@@ -0,0 +1,66 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Preview plays one sound at a time for browsing, cutting off whatever was
+// playing before. The zero value is ready to use.
+type Preview struct {
+	mu      sync.Mutex
+	playing string
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// Play stops the current sound and starts name in the background.
+func (p *Preview) Play(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopLocked()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := PlaySound(ctx, name); err != nil {
+			log.Printf("Error previewing sound '%s': %v", name, err)
+		}
+	}()
+	p.playing, p.cancel, p.done = name, cancel, done
+}
+
+// Toggle stops name if it is still playing and plays it otherwise.
+func (p *Preview) Toggle(name string) {
+	p.mu.Lock()
+	if p.playing == name && p.done != nil {
+		select {
+		case <-p.done:
+		default:
+			p.stopLocked()
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.mu.Unlock()
+	p.Play(name)
+}
+
+// Stop cuts off the current sound and waits for the player to exit.
+func (p *Preview) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopLocked()
+}
+
+func (p *Preview) stopLocked() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+	p.playing, p.cancel, p.done = "", nil, nil
+}
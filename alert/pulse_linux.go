@@ -0,0 +1,393 @@
+package alert
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// The subset of the PulseAudio native protocol needed to play one stream.
+// PipeWire's pulse server speaks it as well.
+const (
+	pulseProtocolVersion = 13
+
+	pulseCommandError                = 0
+	pulseCommandReply                = 2
+	pulseCommandCreatePlaybackStream = 3
+	pulseCommandAuth                 = 8
+	pulseCommandSetClientName        = 9
+	pulseCommandDrainPlaybackStream  = 12
+	pulseCommandRequest              = 61
+
+	pulseControlChannel = 0xFFFFFFFF
+	pulseInvalidIndex   = 0xFFFFFFFF
+	pulseDefault        = 0xFFFFFFFF
+	pulseSampleS16LE    = 3
+	pulseVolumeNorm     = 0x10000
+	pulseCookieLength   = 256
+	pulseMaxWrite       = 64 * 1024
+)
+
+type pulseConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	tag     uint32
+	channel uint32
+	credit  int
+}
+
+func pulseAvailable() error {
+	_, err := pulseSocketPath()
+	return err
+}
+
+func playPulse(ctx context.Context, audio *Audio) error {
+	path, err := pulseSocketPath()
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Closing the socket is the quickest way to silence the stream.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c := &pulseConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	auth := c.command(pulseCommandAuth)
+	auth.u32(pulseProtocolVersion)
+	auth.arbitrary(pulseCookie())
+	reply, err := c.call(auth)
+	if err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	if serverVersion, err := reply.u32(); err != nil || serverVersion&0xFFFF < pulseProtocolVersion {
+		return fmt.Errorf("server protocol version %d is too old", serverVersion&0xFFFF)
+	}
+
+	name := c.command(pulseCommandSetClientName)
+	name.proplist(map[string]string{"application.name": "terminal-timer"})
+	if _, err := c.call(name); err != nil {
+		return fmt.Errorf("set client name: %w", err)
+	}
+
+	if err := c.createStream(audio); err != nil {
+		return fmt.Errorf("create stream: %w", err)
+	}
+
+	data := audio.Bytes()
+	for len(data) > 0 {
+		for c.credit <= 0 {
+			if err := c.waitForRequest(); err != nil {
+				return err
+			}
+		}
+		n := len(data)
+		if n > c.credit {
+			n = c.credit
+		}
+		if n > pulseMaxWrite {
+			n = pulseMaxWrite
+		}
+		if err := c.writePacket(c.channel, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+		c.credit -= n
+	}
+
+	drain := c.command(pulseCommandDrainPlaybackStream)
+	drain.u32(c.channel)
+	if _, err := c.call(drain); err != nil {
+		return fmt.Errorf("drain: %w", err)
+	}
+	return nil
+}
+
+func (c *pulseConn) createStream(audio *Audio) error {
+	positions := []byte{0} // mono
+	if audio.Channels == 2 {
+		positions = []byte{1, 2} // front left, front right
+	}
+
+	t := c.command(pulseCommandCreatePlaybackStream)
+	t.sampleSpec(pulseSampleS16LE, byte(audio.Channels), uint32(audio.SampleRate))
+	t.channelMap(positions)
+	t.u32(pulseInvalidIndex) // default sink
+	t.null()
+	t.u32(pulseDefault) // maxlength
+	t.boolean(false)    // corked
+	t.u32(pulseDefault) // tlength
+	t.u32(pulseDefault) // prebuf
+	t.u32(pulseDefault) // minreq
+	t.u32(0)            // sync id
+	t.cvolume(audio.Channels, pulseVolumeNorm)
+	// no_remap, no_remix, fix_format, fix_rate, fix_channels, no_move,
+	// variable_rate
+	for i := 0; i < 7; i++ {
+		t.boolean(false)
+	}
+	t.boolean(false) // muted
+	t.boolean(false) // adjust_latency
+	t.proplist(map[string]string{"media.name": "Timer alert", "media.role": "event"})
+
+	reply, err := c.call(t)
+	if err != nil {
+		return err
+	}
+	if c.channel, err = reply.u32(); err != nil {
+		return err
+	}
+	if _, err = reply.u32(); err != nil { // sink input index
+		return err
+	}
+	missing, err := reply.u32()
+	c.credit = int(missing)
+	return err
+}
+
+// command starts a control packet with the next tag.
+func (c *pulseConn) command(command uint32) *pulseTags {
+	t := &pulseTags{}
+	t.u32(command)
+	t.u32(c.tag)
+	c.tag++
+	return t
+}
+
+// call sends a command and waits for the matching reply.
+func (c *pulseConn) call(t *pulseTags) (*pulseReader, error) {
+	tag := c.tag - 1
+	if err := c.writePacket(pulseControlChannel, t.Bytes()); err != nil {
+		return nil, err
+	}
+	for {
+		command, replyTag, reply, err := c.readCommand()
+		if err != nil {
+			return nil, err
+		}
+		if replyTag != tag {
+			continue
+		}
+		switch command {
+		case pulseCommandReply:
+			return reply, nil
+		case pulseCommandError:
+			code, _ := reply.u32()
+			return nil, fmt.Errorf("server error %d", code)
+		}
+	}
+}
+
+func (c *pulseConn) waitForRequest() error {
+	for c.credit <= 0 {
+		if _, _, _, err := c.readCommand(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCommand reads control packets, crediting any write requests for our
+// stream on the way, and returns the next command.
+func (c *pulseConn) readCommand() (command, tag uint32, reply *pulseReader, err error) {
+	for {
+		var descriptor [20]byte
+		if _, err := io.ReadFull(c.reader, descriptor[:]); err != nil {
+			return 0, 0, nil, err
+		}
+		length := binary.BigEndian.Uint32(descriptor[0:4])
+		channel := binary.BigEndian.Uint32(descriptor[4:8])
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return 0, 0, nil, err
+		}
+		if channel != pulseControlChannel {
+			continue
+		}
+
+		reply = &pulseReader{data: payload}
+		if command, err = reply.u32(); err != nil {
+			return 0, 0, nil, err
+		}
+		if tag, err = reply.u32(); err != nil {
+			return 0, 0, nil, err
+		}
+		if command == pulseCommandRequest {
+			channel, _ := reply.u32()
+			bytes, _ := reply.u32()
+			if channel == c.channel {
+				c.credit += int(bytes)
+			}
+		}
+		return command, tag, reply, nil
+	}
+}
+
+func (c *pulseConn) writePacket(channel uint32, payload []byte) error {
+	var descriptor [20]byte
+	binary.BigEndian.PutUint32(descriptor[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(descriptor[4:8], channel)
+	if _, err := c.conn.Write(descriptor[:]); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// pulseTags builds a PulseAudio tagstruct.
+type pulseTags struct {
+	bytes.Buffer
+}
+
+func (t *pulseTags) rawU32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	t.Write(b[:])
+}
+
+func (t *pulseTags) u32(v uint32) {
+	t.WriteByte('L')
+	t.rawU32(v)
+}
+
+func (t *pulseTags) str(s string) {
+	t.WriteByte('t')
+	t.WriteString(s)
+	t.WriteByte(0)
+}
+
+func (t *pulseTags) null() {
+	t.WriteByte('N')
+}
+
+func (t *pulseTags) boolean(b bool) {
+	if b {
+		t.WriteByte('1')
+	} else {
+		t.WriteByte('0')
+	}
+}
+
+func (t *pulseTags) arbitrary(data []byte) {
+	t.WriteByte('x')
+	t.rawU32(uint32(len(data)))
+	t.Write(data)
+}
+
+func (t *pulseTags) sampleSpec(format, channels byte, rate uint32) {
+	t.WriteByte('a')
+	t.WriteByte(format)
+	t.WriteByte(channels)
+	t.rawU32(rate)
+}
+
+func (t *pulseTags) channelMap(positions []byte) {
+	t.WriteByte('m')
+	t.WriteByte(byte(len(positions)))
+	t.Write(positions)
+}
+
+func (t *pulseTags) cvolume(channels int, volume uint32) {
+	t.WriteByte('v')
+	t.WriteByte(byte(channels))
+	for i := 0; i < channels; i++ {
+		t.rawU32(volume)
+	}
+}
+
+func (t *pulseTags) proplist(props map[string]string) {
+	t.WriteByte('P')
+	for key, value := range props {
+		t.str(key)
+		t.u32(uint32(len(value) + 1))
+		t.arbitrary(append([]byte(value), 0))
+	}
+	t.null()
+}
+
+// pulseReader reads values back out of a tagstruct.
+type pulseReader struct {
+	data []byte
+}
+
+func (r *pulseReader) u32() (uint32, error) {
+	if len(r.data) < 5 || r.data[0] != 'L' {
+		return 0, errors.New("malformed reply from pulse server")
+	}
+	v := binary.BigEndian.Uint32(r.data[1:5])
+	r.data = r.data[5:]
+	return v, nil
+}
+
+// pulseSocketPath finds the server socket the same way libpulse does for
+// local connections.
+func pulseSocketPath() (string, error) {
+	if server := os.Getenv("PULSE_SERVER"); server != "" {
+		for _, candidate := range strings.Fields(server) {
+			if strings.HasPrefix(candidate, "unix:") {
+				return strings.TrimPrefix(candidate, "unix:"), nil
+			}
+			if strings.HasPrefix(candidate, "/") {
+				return candidate, nil
+			}
+		}
+		return "", errors.New("PULSE_SERVER has no local socket")
+	}
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	path := filepath.Join(runtimeDir, "pulse", "native")
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// pulseCookie returns the auth cookie, or zeros when there is none, which a
+// server relying on socket credentials accepts anyway.
+func pulseCookie() []byte {
+	candidates := []string{os.Getenv("PULSE_COOKIE")}
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		candidates = append(candidates, filepath.Join(configHome, "pulse", "cookie"))
+	}
+	if home, err := homedir.Dir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(home, ".config", "pulse", "cookie"),
+			filepath.Join(home, ".pulse-cookie"))
+	}
+
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if cookie, err := os.ReadFile(candidate); err == nil && len(cookie) >= pulseCookieLength {
+			return cookie[:pulseCookieLength]
+		}
+	}
+	return make([]byte, pulseCookieLength)
+}
@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// Speech settings, set from the config file. Voice is passed to the engine as
+// is and SpeechRate is in words per minute; empty and zero keep the engine's
+// defaults.
+var (
+	Voice      string
+	SpeechRate int
+)
+
+// speechEngine is a text-to-speech command. args ends the options with "--" so
+// text such as "-5 minutes left" isn't read as one.
+type speechEngine struct {
+	command string
+	args    func(text string) []string
+}
+
+// espeakArgs suits espeak, espeak-ng and say, which share -v and a rate in
+// words per minute.
+func espeakArgs(rateFlag string) func(text string) []string {
+	return func(text string) []string {
+		var args []string
+		if Voice != "" {
+			args = append(args, "-v", Voice)
+		}
+		if SpeechRate > 0 {
+			args = append(args, rateFlag, strconv.Itoa(SpeechRate))
+		}
+		return append(args, "--", text)
+	}
+}
+
+// speechEngines are tried in order.
+var speechEngines = []speechEngine{
+	{"espeak-ng", espeakArgs("-s")},
+	{"espeak", espeakArgs("-s")},
+	{"spd-say", func(text string) []string {
+		args := []string{"-w"}
+		if Voice != "" {
+			args = append(args, "-y", Voice)
+		}
+		if SpeechRate > 0 {
+			// spd-say takes -100 to 100 around a default of about 175 words
+			// per minute.
+			rate := (SpeechRate - 175) * 100 / 175
+			if rate > 100 {
+				rate = 100
+			}
+			args = append(args, "-r", strconv.Itoa(rate))
+		}
+		return append(args, "--", text)
+	}},
+	{"say", espeakArgs("-r")},
+}
+
+// SpeechEngine returns the name of the first text-to-speech command found.
+func SpeechEngine() (string, error) {
+	for _, engine := range speechEngines {
+		if util.CmdExists(engine.command) {
+			return engine.command, nil
+		}
+	}
+	return "", errors.New("no text-to-speech command found, install espeak-ng, espeak or spd-say")
+}
+
+// Speak reads text aloud and waits until it is done or ctx is cancelled.
+func Speak(ctx context.Context, text string) error {
+	for _, engine := range speechEngines {
+		if util.CmdExists(engine.command) {
+			return exec.CommandContext(ctx, engine.command, engine.args(text)...).Run()
+		}
+	}
+	_, err := SpeechEngine()
+	return err
+}
@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// Channels picks the ways the timer gets your attention. Terminal rings the
+// bell, flashes the screen and sends a notification through the terminal
+// itself, which also works over SSH. Speech reads the reminder aloud.
+type Channels struct {
+	Sound    bool
+	Desktop  bool
+	Terminal bool
+	Speech   bool
+}
+
+// ChannelsAuto uses sound and desktop notifications locally and the terminal
+// over SSH, where the other two would reach the wrong machine.
+const ChannelsAuto = "auto"
+
+// Active is the set of channels in use, set from the config file.
+var Active = AutoChannels()
+
+// AutoChannels returns the channels ChannelsAuto stands for.
+func AutoChannels() Channels {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return Channels{Terminal: true}
+	}
+	return Channels{Sound: true, Desktop: true}
+}
+
+// ParseChannels reads auto or a comma separated list of sound, desktop,
+// terminal and speech.
+func ParseChannels(value string) (Channels, error) {
+	if strings.TrimSpace(value) == "" || strings.TrimSpace(value) == ChannelsAuto {
+		return AutoChannels(), nil
+	}
+	var channels Channels
+	for _, field := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "":
+		case "sound":
+			channels.Sound = true
+		case "desktop":
+			channels.Desktop = true
+		case "terminal":
+			channels.Terminal = true
+		case "speech":
+			channels.Speech = true
+		default:
+			return Channels{}, fmt.Errorf("unknown alert channel %q: want auto or any of sound, desktop, terminal and speech", strings.TrimSpace(field))
+		}
+	}
+	return channels, nil
+}
+
+func (c Channels) String() string {
+	var names []string
+	if c.Sound {
+		names = append(names, "sound")
+	}
+	if c.Desktop {
+		names = append(names, "desktop")
+	}
+	if c.Terminal {
+		names = append(names, "terminal")
+	}
+	if c.Speech {
+		names = append(names, "speech")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// announce shows the notification on each active channel.
+func announce(note Notification) {
+	if Active.Terminal {
+		notifyTerminal(note)
+	}
+	if Active.Desktop {
+		if err := Notify(note); err != nil {
+			log.Printf("Error showing notification: %v", err)
+		}
+	}
+}
+
+// notifyTerminal asks the terminal to show a notification with OSC 777 where
+// the terminal is known to understand it and OSC 9 otherwise.
+func notifyTerminal(note Notification) {
+	var seq string
+	if usesOSC777() {
+		seq = fmt.Sprintf("\x1b]777;notify;%s;%s\x1b\\", oscText(note.Title), oscText(note.Message))
+	} else {
+		seq = fmt.Sprintf("\x1b]9;%s: %s\x1b\\", oscText(note.Title), oscText(note.Message))
+	}
+	fmt.Fprint(os.Stdout, passthrough(seq))
+}
+
+func usesOSC777() bool {
+	term := os.Getenv("TERM")
+	return os.Getenv("VTE_VERSION") != "" || os.Getenv("KONSOLE_VERSION") != "" ||
+		strings.HasPrefix(term, "rxvt") || strings.HasPrefix(term, "foot") ||
+		os.Getenv("TERM_PROGRAM") == "ghostty"
+}
+
+// oscText strips what would end the escape sequence early.
+func oscText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// passthrough wraps seq so tmux hands it on to the outer terminal.
+func passthrough(seq string) string {
+	if os.Getenv("TMUX") == "" {
+		return seq
+	}
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// ringBell sounds the terminal bell and briefly flashes the screen in reverse
+// video.
+func ringBell() {
+	fmt.Fprint(os.Stdout, "\a")
+	util.Flash()
+}
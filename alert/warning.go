@@ -0,0 +1,59 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Pre-expiry warnings, set from the config file. Warnings lists how long
+// before the end each warning fires, longest first.
+var (
+	Warnings     []time.Duration
+	WarningSound = "Taptap.wav"
+)
+
+// Warn tells the user on the active channels that left is all the time
+// remaining, with the warning sound and a notification. It does not block.
+func Warn(left time.Duration, reminder string) {
+	// Warning sounds are short, so they skip the fade-in.
+	sound := NewSound(WarningSound)
+	sound.FadeIn = 0
+	go func() {
+		if Active.Sound {
+			if err := Play(context.Background(), sound); err != nil {
+				log.Printf("Error playing warning sound: %v", err)
+			}
+		}
+		if Active.Speech {
+			if err := Speak(context.Background(), formatLeft(left)+" remaining"); err != nil {
+				log.Printf("Error speaking warning: %v", err)
+			}
+		}
+	}()
+
+	go announce(Notification{
+		Title:   fmt.Sprintf("%s left", formatLeft(left)),
+		Message: reminder,
+		Urgency: UrgencyNormal,
+	})
+}
+
+// formatLeft renders a warning offset in words, such as "5 minutes".
+func formatLeft(d time.Duration) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return unit(int(d/time.Hour), "hour")
+	case d >= time.Minute && d%time.Minute == 0:
+		return unit(int(d/time.Minute), "minute")
+	default:
+		return unit(int(d.Round(time.Second)/time.Second), "second")
+	}
+}
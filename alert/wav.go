@@ -0,0 +1,280 @@
+package alert
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// WAV audio formats understood by the decoder.
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// WAVFormat describes the audio stored in a WAV file.
+type WAVFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	BitsPerSample uint16
+	// DataSize is the length of the sample data in bytes.
+	DataSize uint32
+}
+
+// ReadWAVHeader parses the RIFF header of a WAV file, leaving r positioned at
+// the first byte of sample data.
+func ReadWAVHeader(r io.Reader) (WAVFormat, error) {
+	var format WAVFormat
+
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return format, errors.New("not a WAV file: too short")
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return format, errors.New("not a WAV file: missing RIFF/WAVE signature")
+	}
+
+	haveFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return format, errors.New("invalid WAV file: no data chunk")
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1<<16 {
+				return format, fmt.Errorf("invalid WAV file: fmt chunk is %d bytes", size)
+			}
+			body := make([]byte, int(size)+int(size%2))
+			if _, err := io.ReadFull(r, body); err != nil {
+				return format, errors.New("invalid WAV file: truncated fmt chunk")
+			}
+			format.AudioFormat = binary.LittleEndian.Uint16(body[0:2])
+			format.Channels = binary.LittleEndian.Uint16(body[2:4])
+			format.SampleRate = binary.LittleEndian.Uint32(body[4:8])
+			format.BitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+			// Extensible files carry the real encoding at the start of the
+			// sub-format GUID.
+			if format.AudioFormat == wavFormatExtensible && size >= 40 {
+				format.AudioFormat = binary.LittleEndian.Uint16(body[24:26])
+			}
+			if err := format.validate(); err != nil {
+				return format, err
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return format, errors.New("invalid WAV file: data chunk before fmt chunk")
+			}
+			format.DataSize = size
+			return format, nil
+		default:
+			if err := skip(r, int64(size)+int64(size%2)); err != nil {
+				return format, fmt.Errorf("invalid WAV file: truncated %q chunk", id)
+			}
+		}
+	}
+}
+
+func (f WAVFormat) validate() error {
+	switch f.AudioFormat {
+	case wavFormatPCM:
+	case wavFormatFloat:
+		if f.BitsPerSample != 32 {
+			return fmt.Errorf("unsupported %d-bit float WAV", f.BitsPerSample)
+		}
+	default:
+		return fmt.Errorf("unsupported WAV encoding %#x", f.AudioFormat)
+	}
+	if f.Channels == 0 || f.SampleRate == 0 {
+		return errors.New("invalid WAV file: no channels or sample rate")
+	}
+	switch f.BitsPerSample {
+	case 8, 16, 24, 32:
+	default:
+		return fmt.Errorf("unsupported WAV sample size of %d bits", f.BitsPerSample)
+	}
+	return nil
+}
+
+// Audio is decoded sound as interleaved signed 16-bit samples, the one format
+// every output can take. It is limited to mono or stereo.
+type Audio struct {
+	Channels   int
+	SampleRate int
+	Samples    []int16
+}
+
+// Frames returns the number of samples per channel.
+func (a *Audio) Frames() int {
+	return len(a.Samples) / a.Channels
+}
+
+// Bytes returns the samples as little-endian PCM.
+func (a *Audio) Bytes() []byte {
+	data := make([]byte, 2*len(a.Samples))
+	for i, sample := range a.Samples {
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(sample))
+	}
+	return data
+}
+
+// DecodeWAV reads a whole WAV file, converting any supported encoding to
+// 16-bit samples. Channels beyond the first two are dropped.
+func DecodeWAV(r io.Reader) (*Audio, error) {
+	format, err := ReadWAVHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Streamed files may leave the data size unset, so read to EOF then.
+	var data []byte
+	if format.DataSize == 0 || format.DataSize == 0xFFFFFFFF {
+		data, err = io.ReadAll(r)
+	} else {
+		data, err = io.ReadAll(io.LimitReader(r, int64(format.DataSize)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inChannels := int(format.Channels)
+	sampleSize := int(format.BitsPerSample) / 8
+	frameSize := inChannels * sampleSize
+	frames := len(data) / frameSize
+
+	audio := &Audio{Channels: inChannels, SampleRate: int(format.SampleRate)}
+	if audio.Channels > 2 {
+		audio.Channels = 2
+	}
+	audio.Samples = make([]int16, 0, frames*audio.Channels)
+
+	for frame := 0; frame < frames; frame++ {
+		for channel := 0; channel < audio.Channels; channel++ {
+			at := frame*frameSize + channel*sampleSize
+			audio.Samples = append(audio.Samples, decodeSample(data[at:at+sampleSize], format.AudioFormat))
+		}
+	}
+	return audio, nil
+}
+
+func decodeSample(b []byte, audioFormat uint16) int16 {
+	switch len(b) {
+	case 1:
+		// 8-bit WAV is the only unsigned encoding.
+		return int16(int(b[0])-128) << 8
+	case 2:
+		return int16(binary.LittleEndian.Uint16(b))
+	case 3:
+		return int16(uint16(b[1]) | uint16(b[2])<<8)
+	}
+	if audioFormat == wavFormatFloat {
+		f := math.Float32frombits(binary.LittleEndian.Uint32(b))
+		return clampSample(float64(f) * math.MaxInt16)
+	}
+	return int16(binary.LittleEndian.Uint32(b) >> 16)
+}
+
+func clampSample(v float64) int16 {
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
+
+// Convert returns the audio resampled to rate and mixed to channels, using
+// linear interpolation. It returns a itself when nothing needs to change.
+func (a *Audio) Convert(rate, channels int) *Audio {
+	if rate == a.SampleRate && channels == a.Channels {
+		return a
+	}
+
+	inFrames := a.Frames()
+	outFrames := int(int64(inFrames) * int64(rate) / int64(a.SampleRate))
+	out := &Audio{Channels: channels, SampleRate: rate, Samples: make([]int16, outFrames*channels)}
+
+	sample := func(frame, channel int) float64 {
+		if frame >= inFrames {
+			frame = inFrames - 1
+		}
+		if a.Channels == 1 {
+			return float64(a.Samples[frame])
+		}
+		if channels == 1 {
+			return (float64(a.Samples[2*frame]) + float64(a.Samples[2*frame+1])) / 2
+		}
+		return float64(a.Samples[frame*a.Channels+channel])
+	}
+
+	step := float64(a.SampleRate) / float64(rate)
+	for frame := 0; frame < outFrames; frame++ {
+		pos := float64(frame) * step
+		base := int(pos)
+		frac := pos - float64(base)
+		for channel := 0; channel < channels; channel++ {
+			v := sample(base, channel)*(1-frac) + sample(base+1, channel)*frac
+			out.Samples[frame*channels+channel] = clampSample(v)
+		}
+	}
+	return out
+}
+
+// WithLevel returns a copy scaled to volume, from 0 to 100, that ramps up
+// from silence over fadeIn. Volume follows a squared curve so that equal
+// steps sound roughly equally far apart.
+func (a *Audio) WithLevel(volume int, fadeIn time.Duration) *Audio {
+	gain := math.Pow(float64(volume)/100, 2)
+	fadeFrames := int(fadeIn.Seconds() * float64(a.SampleRate))
+
+	out := &Audio{Channels: a.Channels, SampleRate: a.SampleRate, Samples: make([]int16, len(a.Samples))}
+	for i, sample := range a.Samples {
+		level := gain
+		if frame := i / a.Channels; frame < fadeFrames {
+			level *= float64(frame) / float64(fadeFrames)
+		}
+		out.Samples[i] = clampSample(float64(sample) * level)
+	}
+	return out
+}
+
+// EncodeWAV writes the audio as a 16-bit PCM WAV file.
+func EncodeWAV(w io.Writer, a *Audio) error {
+	dataSize := uint32(2 * len(a.Samples))
+	blockAlign := uint16(2 * a.Channels)
+
+	var header [44]byte
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], 36+dataSize)
+	copy(header[8:16], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(a.Channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(a.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(a.SampleRate)*uint32(blockAlign))
+	binary.LittleEndian.PutUint16(header[32:34], blockAlign)
+	binary.LittleEndian.PutUint16(header[34:36], 16)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], dataSize)
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(a.Bytes())
+	return err
+}
+
+func skip(r io.Reader, n int64) error {
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
@@ -0,0 +1,220 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// Webhook is an endpoint told when the timer expires. Format picks how the
+// payload is written: json, slack, discord or ntfy.
+type Webhook struct {
+	Format string
+	URL    string
+}
+
+// Webhook delivery, set from the config file. Each attempt gives up after
+// WebhookTimeout, and failed attempts are retried WebhookRetries times with
+// the wait doubling from webhookBackoff.
+var (
+	Webhooks       []Webhook
+	WebhookTimeout = 10 * time.Second
+	WebhookRetries = 3
+	webhookBackoff = time.Second
+	webhooksSent   sync.WaitGroup
+)
+
+// WebhookPayload describes a timer that has expired.
+type WebhookPayload struct {
+	Reminder string `json:"reminder"`
+	// Duration is in seconds.
+	Duration int       `json:"duration"`
+	Started  time.Time `json:"started"`
+	Ended    time.Time `json:"ended"`
+	Hostname string    `json:"hostname"`
+}
+
+// NewWebhookPayload fills in the payload for a timer of duration that started
+// at started and has just ended.
+func NewWebhookPayload(reminder string, duration time.Duration, started time.Time) WebhookPayload {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Printf("Error looking up hostname: %v", err)
+	}
+	return WebhookPayload{
+		Reminder: reminder,
+		Duration: int(duration.Round(time.Second) / time.Second),
+		Started:  started,
+		Ended:    time.Now(),
+		Hostname: hostname,
+	}
+}
+
+// webhookFormat is how a payload is sent to one kind of service.
+type webhookFormat struct {
+	contentType string
+	headers     map[string]string
+	body        *template.Template
+}
+
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"seconds": func(seconds int) string {
+		return util.FormatShort(time.Duration(seconds) * time.Second)
+	},
+}
+
+func webhookTemplate(text string) *template.Template {
+	return template.Must(template.New("").Funcs(webhookFuncs).Parse(text))
+}
+
+var webhookFormats = map[string]webhookFormat{
+	"json": {
+		contentType: "application/json",
+		body:        webhookTemplate(`{{json .}}`),
+	},
+	"slack": {
+		contentType: "application/json",
+		body:        webhookTemplate(`{"text": {{json (printf ":alarm_clock: *%s* (%s timer on %s)" .Reminder (seconds .Duration) .Hostname)}}}`),
+	},
+	"discord": {
+		contentType: "application/json",
+		body:        webhookTemplate(`{"content": {{json (printf ":alarm_clock: **%s** (%s timer on %s)" .Reminder (seconds .Duration) .Hostname)}}}`),
+	},
+	"ntfy": {
+		contentType: "text/plain; charset=utf-8",
+		headers:     map[string]string{"Title": "Timer Completed", "Tags": "alarm_clock"},
+		body:        webhookTemplate(`{{.Reminder}} ({{seconds .Duration}} timer on {{.Hostname}})`),
+	},
+}
+
+// ParseWebhooks reads a comma separated list of URLs, each optionally preceded
+// by its format, such as "slack https://hooks.slack.com/services/...".
+func ParseWebhooks(value string) ([]Webhook, error) {
+	var webhooks []Webhook
+	for _, field := range strings.Split(value, ",") {
+		words := strings.Fields(field)
+		webhook := Webhook{Format: "json"}
+		switch len(words) {
+		case 0:
+			continue
+		case 1:
+			webhook.URL = words[0]
+		case 2:
+			webhook.Format, webhook.URL = strings.ToLower(words[0]), words[1]
+		default:
+			return nil, fmt.Errorf("webhook %q: want an optional format followed by a URL", strings.TrimSpace(field))
+		}
+		if err := webhook.Validate(); err != nil {
+			return nil, fmt.Errorf("webhook %q: %v", strings.TrimSpace(field), err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// Validate checks the format is known and the URL is http or https.
+func (w Webhook) Validate() error {
+	if _, ok := webhookFormats[w.Format]; !ok {
+		return fmt.Errorf("unknown format %q, want json, slack, discord or ntfy", w.Format)
+	}
+	if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("want an http or https URL")
+	}
+	return nil
+}
+
+// SendWebhooks posts the payload to every configured webhook in the
+// background.
+func SendWebhooks(payload WebhookPayload) {
+	for _, webhook := range Webhooks {
+		webhooksSent.Add(1)
+		go func(webhook Webhook) {
+			defer webhooksSent.Done()
+			if err := sendWebhook(webhook, payload); err != nil {
+				log.Printf("Error sending webhook to %s: %v", webhook.URL, err)
+			}
+		}(webhook)
+	}
+}
+
+// WaitForWebhooks waits up to timeout for webhooks still being sent.
+func WaitForWebhooks(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		webhooksSent.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// sendWebhook posts the payload, retrying with backoff on network errors and
+// server errors.
+func sendWebhook(webhook Webhook, payload WebhookPayload) error {
+	format := webhookFormats[webhook.Format]
+	var body bytes.Buffer
+	if err := format.body.Execute(&body, payload); err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: WebhookTimeout}
+	backoff := webhookBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = postWebhook(client, webhook.URL, format, body.Bytes())
+		if err == nil || !retry || attempt >= WebhookRetries {
+			return err
+		}
+		if util.Logging {
+			log.Printf("Webhook to %s failed, retrying in %v: %v", webhook.URL, backoff, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postWebhook makes one attempt and reports whether a failure is worth
+// retrying.
+func postWebhook(client *http.Client, target string, format webhookFormat, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", format.contentType)
+	for key, value := range format.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("server replied %s", resp.Status)
+	default:
+		return false, fmt.Errorf("server replied %s", resp.Status)
+	}
+}
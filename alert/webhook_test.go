@@ -0,0 +1,228 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testPayload = WebhookPayload{
+	Reminder: "Tea",
+	Duration: 300,
+	Started:  time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC),
+	Ended:    time.Date(2024, 1, 2, 15, 5, 0, 0, time.UTC),
+	Hostname: "kettle",
+}
+
+// webhookSettings shortens the retry and timeout settings for a test and
+// restores them when it ends.
+func webhookSettings(t *testing.T, timeout, backoff time.Duration, retries int) {
+	t.Helper()
+	oldTimeout, oldBackoff, oldRetries, oldWebhooks := WebhookTimeout, webhookBackoff, WebhookRetries, Webhooks
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		WebhookTimeout, webhookBackoff, WebhookRetries, Webhooks = oldTimeout, oldBackoff, oldRetries, oldWebhooks
+		log.SetOutput(output)
+	})
+	WebhookTimeout, webhookBackoff, WebhookRetries = timeout, backoff, retries
+}
+
+// attempt is one request received by a test server.
+type attempt struct {
+	at     time.Time
+	header http.Header
+	body   string
+}
+
+// webhookServer answers each request with the next status in statuses,
+// repeating the last one, and records what it was sent.
+func webhookServer(t *testing.T, statuses ...int) (*httptest.Server, func() []attempt) {
+	t.Helper()
+	var mu sync.Mutex
+	var attempts []attempt
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		attempts = append(attempts, attempt{time.Now(), r.Header.Clone(), string(body)})
+		status := statuses[len(statuses)-1]
+		if len(attempts) <= len(statuses) {
+			status = statuses[len(attempts)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []attempt {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]attempt(nil), attempts...)
+	}
+}
+
+func TestWebhookFormats(t *testing.T) {
+	tests := []struct {
+		format      string
+		contentType string
+		headers     map[string]string
+		// field is the JSON field holding the message, empty for plain text.
+		field string
+		want  string
+	}{
+		{format: "slack", contentType: "application/json", field: "text", want: ":alarm_clock: *Tea* (5m timer on kettle)"},
+		{format: "discord", contentType: "application/json", field: "content", want: ":alarm_clock: **Tea** (5m timer on kettle)"},
+		{format: "ntfy", contentType: "text/plain; charset=utf-8", headers: map[string]string{"Title": "Timer Completed", "Tags": "alarm_clock"}, want: "Tea (5m timer on kettle)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			webhookSettings(t, time.Second, time.Millisecond, 0)
+			server, attempts := webhookServer(t, http.StatusOK)
+
+			if err := sendWebhook(Webhook{Format: tt.format, URL: server.URL}, testPayload); err != nil {
+				t.Fatal(err)
+			}
+
+			got := attempts()
+			if len(got) != 1 {
+				t.Fatalf("got %d requests, want 1", len(got))
+			}
+			if contentType := got[0].header.Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.contentType)
+			}
+			for key, value := range tt.headers {
+				if got := got[0].header.Get(key); got != value {
+					t.Errorf("%s = %q, want %q", key, got, value)
+				}
+			}
+
+			message := got[0].body
+			if tt.field != "" {
+				var body map[string]string
+				if err := json.Unmarshal([]byte(got[0].body), &body); err != nil {
+					t.Fatalf("body %q is not JSON: %v", got[0].body, err)
+				}
+				message = body[tt.field]
+			}
+			if message != tt.want {
+				t.Errorf("message = %q, want %q", message, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookJSON(t *testing.T) {
+	webhookSettings(t, time.Second, time.Millisecond, 0)
+	server, attempts := webhookServer(t, http.StatusNoContent)
+
+	if err := sendWebhook(Webhook{Format: "json", URL: server.URL}, testPayload); err != nil {
+		t.Fatal(err)
+	}
+
+	got := attempts()
+	if len(got) != 1 {
+		t.Fatalf("got %d requests, want 1", len(got))
+	}
+	if contentType := got[0].header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal([]byte(got[0].body), &payload); err != nil {
+		t.Fatalf("body %q is not JSON: %v", got[0].body, err)
+	}
+	if payload != testPayload {
+		t.Errorf("payload = %+v, want %+v", payload, testPayload)
+	}
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+	webhookSettings(t, time.Second, backoff, 3)
+	server, attempts := webhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+
+	if err := sendWebhook(Webhook{Format: "json", URL: server.URL}, testPayload); err != nil {
+		t.Fatal(err)
+	}
+
+	got := attempts()
+	if len(got) != 3 {
+		t.Fatalf("got %d requests, want 3: a 503, a 429 and the one that succeeded", len(got))
+	}
+	if gap := got[1].at.Sub(got[0].at); gap < backoff {
+		t.Errorf("first retry came after %v, want at least %v", gap, backoff)
+	}
+	if gap := got[2].at.Sub(got[1].at); gap < 2*backoff {
+		t.Errorf("second retry came after %v, want the backoff doubled to at least %v", gap, 2*backoff)
+	}
+}
+
+func TestWebhookGivesUpAfterRetries(t *testing.T) {
+	webhookSettings(t, time.Second, time.Millisecond, 2)
+	server, attempts := webhookServer(t, http.StatusInternalServerError)
+
+	if err := sendWebhook(Webhook{Format: "json", URL: server.URL}, testPayload); err == nil {
+		t.Fatal("got no error from a server that always fails")
+	}
+	if got := len(attempts()); got != 3 {
+		t.Errorf("got %d requests, want the first and 2 retries", got)
+	}
+}
+
+func TestWebhookNoRetryOnClientError(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound} {
+		webhookSettings(t, time.Second, time.Millisecond, 3)
+		server, attempts := webhookServer(t, status)
+
+		if err := sendWebhook(Webhook{Format: "json", URL: server.URL}, testPayload); err == nil {
+			t.Errorf("got no error for status %d", status)
+		}
+		if got := len(attempts()); got != 1 {
+			t.Errorf("got %d requests for status %d, want 1", got, status)
+		}
+	}
+}
+
+// slowServer holds every request until the test ends.
+func slowServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	return server
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	webhookSettings(t, 50*time.Millisecond, time.Millisecond, 0)
+	server := slowServer(t)
+
+	start := time.Now()
+	if err := sendWebhook(Webhook{Format: "json", URL: server.URL}, testPayload); err == nil {
+		t.Fatal("got no error from a server that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %v, want about WebhookTimeout (%v)", elapsed, WebhookTimeout)
+	}
+}
+
+func TestWaitForWebhooksStopsWaiting(t *testing.T) {
+	webhookSettings(t, 10*time.Second, time.Millisecond, 0)
+	server := slowServer(t)
+	Webhooks = []Webhook{{Format: "json", URL: server.URL}}
+
+	SendWebhooks(testPayload)
+	start := time.Now()
+	WaitForWebhooks(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitForWebhooks returned after %v, want about 50ms", elapsed)
+	}
+}
@@ -1,12 +1,112 @@
 package art
 
 import (
+	"math"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/common-nighthawk/go-figure"
 )
 
+// fallbackFonts is the ladder tried when the configured font does not fit,
+// ordered from the largest rendering to the smallest.
+var fallbackFonts = []string{"colossal", "banner3", "big", "standard", "small", "mini", "term"}
+
+// fallbackHeights caches how many rows each fallback font renders in.
+var (
+	fallbackHeights   = map[string]int{}
+	fallbackHeightsMu sync.Mutex
+)
+
 func GetAsciiArt(message string, font string) []string {
+	if IsNativeFont(font) {
+		if asciiArt := RenderDigits(message, font, math.MaxInt32, math.MaxInt32, 1); asciiArt != nil {
+			return asciiArt
+		}
+		font = ""
+	}
+	if IsCustomFont(font) {
+		if custom, err := loadCustomFont(font); err == nil {
+			return custom.render(message)
+		}
+		font = ""
+	}
 	mainAsciiArt := figure.NewFigure(message, font, true)
 	return strings.Split(mainAsciiArt.String(), "\n")
 }
+
+// FitAsciiArt renders message in the largest font that fits in a width x height
+// area. The configured font is tried first, then the fallback fonts smaller
+// than it. Native fonts are scaled to fill the area instead. It returns nil
+// when not even the smallest font fits.
+func FitAsciiArt(message, font string, width, height int) []string {
+	var asciiArt []string
+	if IsNativeFont(font) {
+		if asciiArt = RenderDigits(message, font, width, height, 0); asciiArt != nil {
+			return asciiArt
+		}
+		// Fall back to fonts smaller than the native font at its base size.
+		asciiArt = RenderDigits(message, font, math.MaxInt32, math.MaxInt32, 1)
+	} else if asciiArt = GetAsciiArt(message, font); fits(asciiArt, width, height) {
+		return asciiArt
+	}
+
+	for _, fallback := range smallerFonts(font, asciiArt) {
+		asciiArt := GetAsciiArt(message, fallback)
+		if fits(asciiArt, width, height) {
+			return asciiArt
+		}
+	}
+	return nil
+}
+
+// smallerFonts returns the part of the fallback ladder below font, whose
+// rendering is asciiArt. Fonts outside the ladder are placed by height, and
+// the whole ladder is used when font couldn't render the text at all, as
+// native fonts can't with letters.
+func smallerFonts(font string, asciiArt []string) []string {
+	for i, fallback := range fallbackFonts {
+		if fallback == font {
+			return fallbackFonts[i+1:]
+		}
+	}
+	if asciiArt == nil {
+		return fallbackFonts
+	}
+	_, height := Size(asciiArt)
+	for i, fallback := range fallbackFonts {
+		if fallbackHeight(fallback) < height {
+			return fallbackFonts[i:]
+		}
+	}
+	return fallbackFonts[len(fallbackFonts)-1:]
+}
+
+// fallbackHeight is the number of rows font renders a line of text in.
+func fallbackHeight(font string) int {
+	fallbackHeightsMu.Lock()
+	defer fallbackHeightsMu.Unlock()
+	height, ok := fallbackHeights[font]
+	if !ok {
+		_, height = Size(GetAsciiArt("0", font))
+		fallbackHeights[font] = height
+	}
+	return height
+}
+
+// Size returns the width and height of a rendered block of art in cells.
+func Size(asciiArt []string) (int, int) {
+	width := 0
+	for _, line := range asciiArt {
+		if n := utf8.RuneCountInString(line); n > width {
+			width = n
+		}
+	}
+	return width, len(asciiArt)
+}
+
+func fits(asciiArt []string, width, height int) bool {
+	artWidth, artHeight := Size(asciiArt)
+	return artWidth <= width && artHeight <= height
+}
@@ -0,0 +1,353 @@
+package art
+
+import (
+	"math"
+	"strings"
+)
+
+// NativeFonts are the built-in digit renderers. Unlike FIGlet fonts they are
+// drawn on a pixel canvas and scaled to fill whatever area is available.
+var NativeFonts = []string{"seven-segment", "full-block", "half-block", "braille"}
+
+// encoder turns a pixel canvas into terminal cells. Each cell covers cx by cy
+// pixels.
+type encoder struct {
+	cx, cy int
+	cell   func(canvas *canvas, x, y int) rune
+}
+
+var (
+	fullBlock = encoder{1, 1, func(c *canvas, x, y int) rune {
+		if c.at(x, y) {
+			return '█'
+		}
+		return ' '
+	}}
+
+	halfBlock = encoder{1, 2, func(c *canvas, x, y int) rune {
+		switch top, bottom := c.at(x, y), c.at(x, y+1); {
+		case top && bottom:
+			return '█'
+		case top:
+			return '▀'
+		case bottom:
+			return '▄'
+		}
+		return ' '
+	}}
+
+	// braille dots are numbered down the left column then the right, with the
+	// bottom row added later in the standard, hence the irregular bit order.
+	braille = encoder{2, 4, func(c *canvas, x, y int) rune {
+		bits := [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+		var dots rune
+		for dy := 0; dy < 4; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				if c.at(x+dx, y+dy) {
+					dots |= bits[dy][dx]
+				}
+			}
+		}
+		if dots == 0 {
+			return ' '
+		}
+		return 0x2800 + dots
+	}}
+)
+
+// IsNativeFont reports whether font names one of the built-in renderers.
+func IsNativeFont(font string) bool {
+	for _, native := range NativeFonts {
+		if native == font {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderDigits draws message with a native renderer scaled to fill a width x
+// height cell area. A scale of 0 fills the area; any other value is used as
+// is. It returns nil when the message has characters the renderer cannot
+// draw or the area is too small to draw it legibly.
+func RenderDigits(message, font string, width, height int, scale float64) []string {
+	for _, char := range message {
+		if _, ok := glyphs[char]; !ok {
+			return nil
+		}
+	}
+
+	switch font {
+	case "seven-segment":
+		return renderSegments(message, width, height, scale)
+	case "full-block":
+		return renderBitmap(message, fullBlock, width, height, scale)
+	case "half-block":
+		return renderBitmap(message, halfBlock, width, height, scale)
+	case "braille":
+		return renderBitmap(message, braille, width, height, scale)
+	}
+	return nil
+}
+
+// renderBitmap scales the 5x7 glyphs with nearest-neighbour sampling. The
+// scale is the number of pixels per glyph pixel vertically; horizontally it
+// is stretched so glyph pixels come out square on a 1:2 terminal cell.
+func renderBitmap(message string, enc encoder, width, height int, scale float64) []string {
+	source := bitmapCanvas(message)
+	aspect := float64(2*enc.cx) / float64(enc.cy)
+
+	if scale <= 0 {
+		scale = math.Min(
+			float64(height*enc.cy)/float64(source.height),
+			float64(width*enc.cx)/(float64(source.width)*aspect),
+		)
+	}
+	targetWidth := int(float64(source.width) * scale * aspect)
+	targetHeight := int(float64(source.height) * scale)
+	if targetWidth < source.width || targetHeight < source.height ||
+		targetWidth > width*enc.cx || targetHeight > height*enc.cy {
+		return nil
+	}
+
+	target := newCanvas(targetWidth, targetHeight)
+	for y := 0; y < targetHeight; y++ {
+		for x := 0; x < targetWidth; x++ {
+			if source.at(x*source.width/targetWidth, y*source.height/targetHeight) {
+				target.set(x, y)
+			}
+		}
+	}
+	return target.encode(enc)
+}
+
+// renderSegments draws each digit as a seven-segment display. The segments
+// are laid out directly at the target size so strokes stay even at any scale.
+func renderSegments(message string, width, height int, scale float64) []string {
+	enc := halfBlock
+	digitHeight := height * enc.cy
+	if scale > 0 {
+		digitHeight = int(scale * segmentBaseHeight)
+	}
+
+	for ; digitHeight >= segmentBaseHeight; digitHeight-- {
+		layout := newSegmentLayout(digitHeight)
+		if layout.width(message) <= width*enc.cx && digitHeight <= height*enc.cy {
+			target := newCanvas(layout.width(message), digitHeight)
+			x := 0
+			for _, char := range message {
+				layout.draw(target, x, char)
+				x += layout.advance(char)
+			}
+			return target.encode(enc)
+		}
+		if scale > 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// segmentBaseHeight is the smallest digit height, in pixels, at which the
+// seven segments are still distinguishable.
+const segmentBaseHeight = 7
+
+// Segment bits, in the conventional a-g order.
+const (
+	segA = 1 << iota // top
+	segB             // top right
+	segC             // bottom right
+	segD             // bottom
+	segE             // bottom left
+	segF             // top left
+	segG             // middle
+)
+
+var segmentDigits = map[rune]int{
+	'0': segA | segB | segC | segD | segE | segF,
+	'1': segB | segC,
+	'2': segA | segB | segG | segE | segD,
+	'3': segA | segB | segG | segC | segD,
+	'4': segF | segG | segB | segC,
+	'5': segA | segF | segG | segC | segD,
+	'6': segA | segF | segG | segE | segC | segD,
+	'7': segA | segB | segC,
+	'8': segA | segB | segC | segD | segE | segF | segG,
+	'9': segA | segB | segC | segD | segF | segG,
+	'-': segG,
+}
+
+type segmentLayout struct {
+	digitWidth, height, thickness, gap int
+}
+
+func newSegmentLayout(height int) segmentLayout {
+	thickness := height / 7
+	if thickness < 1 {
+		thickness = 1
+	}
+	return segmentLayout{
+		digitWidth: height * 4 / 7,
+		height:     height,
+		thickness:  thickness,
+		gap:        thickness * 2,
+	}
+}
+
+func (l segmentLayout) glyphWidth(char rune) int {
+	switch char {
+	case ':', '.':
+		return l.thickness
+	case ' ':
+		return l.digitWidth / 2
+	}
+	return l.digitWidth
+}
+
+func (l segmentLayout) advance(char rune) int {
+	return l.glyphWidth(char) + l.gap
+}
+
+func (l segmentLayout) width(message string) int {
+	total := 0
+	for _, char := range message {
+		total += l.advance(char)
+	}
+	if total > 0 {
+		total -= l.gap
+	}
+	return total
+}
+
+func (l segmentLayout) draw(c *canvas, x0 int, char rune) {
+	t, w, h := l.thickness, l.digitWidth, l.height
+	mid := (h - t) / 2
+
+	switch char {
+	case ':':
+		c.fill(x0, h/3-t/2, t, t)
+		c.fill(x0, 2*h/3-t/2, t, t)
+		return
+	case '.':
+		c.fill(x0, h-t, t, t)
+		return
+	}
+
+	segments := segmentDigits[char]
+	if segments&segA != 0 {
+		c.fill(x0, 0, w, t)
+	}
+	if segments&segB != 0 {
+		c.fill(x0+w-t, 0, t, mid+t)
+	}
+	if segments&segC != 0 {
+		c.fill(x0+w-t, mid, t, h-mid)
+	}
+	if segments&segD != 0 {
+		c.fill(x0, h-t, w, t)
+	}
+	if segments&segE != 0 {
+		c.fill(x0, mid, t, h-mid)
+	}
+	if segments&segF != 0 {
+		c.fill(x0, 0, t, mid+t)
+	}
+	if segments&segG != 0 {
+		c.fill(x0, mid, w, t)
+	}
+}
+
+// glyphs is a 5x7 pixel font covering what a clock needs. Narrow glyphs are
+// simply given fewer columns.
+var glyphs = map[rune][]string{
+	'0': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	':': {".", ".", "#", ".", "#", ".", "."},
+	'.': {".", ".", ".", ".", ".", ".", "#"},
+	'-': {"...", "...", "...", "###", "...", "...", "..."},
+	' ': {"...", "...", "...", "...", "...", "...", "..."},
+}
+
+const glyphHeight = 7
+
+// bitmapCanvas lays out message in the 5x7 font with one blank column
+// between glyphs.
+func bitmapCanvas(message string) *canvas {
+	width := 0
+	for _, char := range message {
+		width += len(glyphs[char][0]) + 1
+	}
+	if width > 0 {
+		width--
+	}
+
+	c := newCanvas(width, glyphHeight)
+	x0 := 0
+	for _, char := range message {
+		glyph := glyphs[char]
+		for y, row := range glyph {
+			for x, pixel := range row {
+				if pixel == '#' {
+					c.set(x0+x, y)
+				}
+			}
+		}
+		x0 += len(glyph[0]) + 1
+	}
+	return c
+}
+
+// canvas is a monochrome pixel buffer.
+type canvas struct {
+	width, height int
+	pixels        []bool
+}
+
+func newCanvas(width, height int) *canvas {
+	return &canvas{width: width, height: height, pixels: make([]bool, width*height)}
+}
+
+func (c *canvas) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.width || y >= c.height {
+		return false
+	}
+	return c.pixels[y*c.width+x]
+}
+
+func (c *canvas) set(x, y int) {
+	if x >= 0 && y >= 0 && x < c.width && y < c.height {
+		c.pixels[y*c.width+x] = true
+	}
+}
+
+func (c *canvas) fill(x0, y0, width, height int) {
+	for y := y0; y < y0+height; y++ {
+		for x := x0; x < x0+width; x++ {
+			c.set(x, y)
+		}
+	}
+}
+
+// encode converts the canvas to lines of terminal cells.
+func (c *canvas) encode(enc encoder) []string {
+	rows := (c.height + enc.cy - 1) / enc.cy
+	cols := (c.width + enc.cx - 1) / enc.cx
+
+	lines := make([]string, rows)
+	var line strings.Builder
+	for row := 0; row < rows; row++ {
+		line.Reset()
+		for col := 0; col < cols; col++ {
+			line.WriteRune(enc.cell(c, col*enc.cx, row*enc.cy))
+		}
+		lines[row] = line.String()
+	}
+	return lines
+}
@@ -0,0 +1,249 @@
+package art
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FontDir is searched for custom FIGlet fonts before the embedded set.
+var FontDir string
+
+// fontExtensions are the file suffixes recognised in FontDir. FIGlet's own
+// compressed fonts are zip archives that keep the .flf suffix.
+var fontExtensions = []string{".flf", ".flf.gz"}
+
+var (
+	customFontsMu sync.Mutex
+	customFonts   = map[string]*figletFont{}
+)
+
+// figletFont is a parsed FIGlet font holding the required printable ASCII
+// characters, space through tilde.
+type figletFont struct {
+	hardblank rune
+	height    int
+	baseline  int
+	reverse   bool
+	letters   [95][]string
+}
+
+// CustomFonts lists the fonts found in FontDir by name.
+func CustomFonts() ([]string, error) {
+	if FontDir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(FontDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var fonts []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, ext := range fontExtensions {
+			if strings.HasSuffix(entry.Name(), ext) {
+				fonts = append(fonts, strings.TrimSuffix(entry.Name(), ext))
+				break
+			}
+		}
+	}
+	sort.Strings(fonts)
+	return fonts, nil
+}
+
+// IsCustomFont reports whether font refers to a file on disk, either by path
+// or by name inside FontDir.
+func IsCustomFont(font string) bool {
+	return fontPath(font) != ""
+}
+
+// ValidateFont parses a custom font and reports the first problem found.
+func ValidateFont(font string) error {
+	_, err := loadCustomFont(font)
+	return err
+}
+
+func fontPath(font string) string {
+	if font == "" {
+		return ""
+	}
+	if strings.ContainsRune(font, filepath.Separator) || strings.HasSuffix(font, ".flf") {
+		if _, err := os.Stat(font); err == nil {
+			return font
+		}
+		return ""
+	}
+	if FontDir == "" {
+		return ""
+	}
+	for _, ext := range fontExtensions {
+		path := filepath.Join(FontDir, font+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func loadCustomFont(font string) (*figletFont, error) {
+	customFontsMu.Lock()
+	defer customFontsMu.Unlock()
+
+	if parsed, ok := customFonts[font]; ok {
+		return parsed, nil
+	}
+
+	path := fontPath(font)
+	if path == "" {
+		return nil, fmt.Errorf("font %q not found", font)
+	}
+	data, err := readFontFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	parsed, err := parseFiglet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	customFonts[font] = parsed
+	return parsed, nil
+}
+
+// readFontFile returns the font text, unpacking zip and gzip compressed files.
+func readFontFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		if len(archive.File) == 0 {
+			return nil, fmt.Errorf("empty font archive")
+		}
+		file, err := archive.File[0].Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	case bytes.HasPrefix(data, []byte("\x1f\x8b")):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	return data, nil
+}
+
+// parseFiglet reads a FIGlet 2 font. Errors carry the offending line number.
+func parseFiglet(data []byte) (*figletFont, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		line++
+		return strings.TrimRight(scanner.Text(), "\r"), true
+	}
+
+	header, ok := next()
+	if !ok || !strings.HasPrefix(header, "flf2a") || len(header) < 6 {
+		return nil, fmt.Errorf("line 1: not a FIGlet font (missing flf2a signature)")
+	}
+
+	font := &figletFont{hardblank: rune(header[5])}
+	fields := strings.Fields(header[6:])
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("line 1: header has %d fields, want at least 5", len(fields))
+	}
+	numbers := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("line 1: header field %d: %q is not a number", i+1, field)
+		}
+		numbers[i] = n
+	}
+	font.height, font.baseline = numbers[0], numbers[1]
+	if font.height < 1 {
+		return nil, fmt.Errorf("line 1: invalid height %d", font.height)
+	}
+	commentLines := numbers[4]
+	font.reverse = len(numbers) > 5 && numbers[5] == 1
+
+	for i := 0; i < commentLines; i++ {
+		if _, ok := next(); !ok {
+			return nil, fmt.Errorf("line %d: unexpected end of file in comments", line+1)
+		}
+	}
+
+	for char := range font.letters {
+		rows := make([]string, font.height)
+		for row := range rows {
+			text, ok := next()
+			if !ok {
+				return nil, fmt.Errorf("line %d: unexpected end of file in character %q", line+1, rune(char+' '))
+			}
+			if text == "" {
+				return nil, fmt.Errorf("line %d: missing end mark in character %q", line, rune(char+' '))
+			}
+			endMark := text[len(text)-1:]
+			rows[row] = strings.TrimRight(text, endMark)
+		}
+		font.letters[char] = rows
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return font, nil
+}
+
+// render lays out message the way go-figure does: full width, no smushing.
+func (font *figletFont) render(message string) []string {
+	runes := []rune(message)
+	if font.reverse {
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+	}
+
+	var lines []string
+	for row := 0; row < font.height; row++ {
+		var line strings.Builder
+		for _, char := range runes {
+			if char < ' ' || char > '~' {
+				char = '?'
+			}
+			line.WriteString(font.letters[char-' '][row])
+		}
+		text := strings.TrimRight(strings.ReplaceAll(line.String(), string(font.hardblank), " "), " ")
+		if row < font.baseline || text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return append(lines, "")
+}
@@ -2,29 +2,34 @@ package config
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/cameroncuttingedge/terminal-timer/alert"
+	"github.com/cameroncuttingedge/terminal-timer/art"
 	"github.com/cameroncuttingedge/terminal-timer/display"
 	"github.com/cameroncuttingedge/terminal-timer/util"
 )
 
 type ValidItemChecker func() ([]string, error)
 
+// ItemValidator reports why a value cannot be used, or nil if it can.
+type ItemValidator func(string) error
+
 func CheckIfConfigChangesRequested() {
     var shouldExit bool
 
-    // Setting a new font
-    if *util.SetFontFlag != "" {
-        updateConfiguration("font", *util.SetFontFlag, listValidFonts)
-        shouldExit = true
-    }
-
-    // Setting a new sound
-    if *util.SetSoundFlag != "" && !shouldExit {
-        updateConfiguration("sound", *util.SetSoundFlag, alert.ListValidSounds)
+    // Changing a setting in the config file
+    if flag.Arg(0) == "config" {
+        if err := runConfigCommand(flag.Args()[1:]); err != nil {
+            fmt.Println(err)
+            os.Exit(2)
+        }
         shouldExit = true
     }
 
@@ -44,24 +49,30 @@ func CheckIfConfigChangesRequested() {
         sounds, err := alert.ListValidSounds()
         if err != nil {
             log.Printf("Error getting sounds: %v", err)
-        } else {
-            display.RenderPrettyData(sounds)
         }
+        userSounds, err := alert.ListUserSounds()
+        if err != nil {
+            log.Printf("Error getting user sounds: %v", err)
+        }
+        display.RenderGroupedData([]display.DataGroup{
+            {Title: "Built-in", Items: sounds},
+            {Title: "User (" + alert.UserSoundDir + ")", Items: userSounds},
+        })
         shouldExit = true
     }
 
-    if *util.PreviewFontFlag != "" && !shouldExit {
-        display.RenderFontExample(*util.PreviewFontFlag)
+    if *util.PreviewFontFlag && !shouldExit {
+        pickFont()
         shouldExit = true
     }
 
-    if *util.PreviewSoundFlag != "" && !shouldExit {
-        tmpfile, err := alert.PrepareSoundFile(*util.PreviewSoundFlag)
-        if err != nil {
-            log.Printf("Error preparing sound file: %v", err)
-        } else {
-            alert.ExecuteSoundPlayback(tmpfile)
-        }
+    if *util.PreviewSoundFlag && !shouldExit {
+        pickSound()
+        shouldExit = true
+    }
+
+    if *util.DoctorFlag && !shouldExit {
+        printAudioDoctor()
         shouldExit = true
     }
 
@@ -70,6 +81,15 @@ func CheckIfConfigChangesRequested() {
         shouldExit = true
     }
 
+    // Managing presets
+    if flag.Arg(0) == "preset" && !shouldExit {
+        if err := runPresetCommand(flag.Args()[1:]); err != nil {
+            fmt.Println(err)
+            os.Exit(2)
+        }
+        shouldExit = true
+    }
+
     if shouldExit {
         util.Cleanup(false)
         os.Exit(0) 
@@ -95,46 +115,172 @@ func listValidFonts() ([]string, error) {
 		return nil, err
 	}
 
+	fonts = append(fonts, art.NativeFonts...)
+
+	custom, err := art.CustomFonts()
+	if err != nil {
+		log.Printf("Error listing custom fonts: %v", err)
+	}
+	fonts = append(fonts, custom...)
+
 	return fonts, nil
 }
 
-func updateConfiguration(configType, newValue string, checker ValidItemChecker) {
-	fmt.Printf("Setting new %s to: %s\n", configType, newValue)
+// pickFont opens the interactive font browser, previewing the countdown the
+// current flags would start, and saves the chosen font.
+func pickFont() {
+	fonts, err := listValidFonts()
+	if err != nil {
+		log.Printf("Error getting fonts: %v", err)
+		return
+	}
+
+	totalSeconds, err := util.CalculateTotalSeconds(*util.TimerFlag, *util.AlarmFlag, flag.Arg(0))
+	if err != nil {
+		totalSeconds = 0
+	}
+	sample := util.FormatRemaining(time.Duration(totalSeconds) * time.Second)
 
-	// Use IsValidItem to validate the new value
-	if !isValidItem(newValue, checker) {
+	font, ok := display.PickFont(fonts, Font, sample)
+	if !ok {
+		return
+	}
+	if err := UpdateConfig("font", font); err != nil {
+		fmt.Printf("Error updating font configuration: %v\n", err)
 		return
 	}
+	fmt.Printf("Font set to %s.\n", font)
+}
 
-	err := UpdateConfig(configType, newValue)
+// pickSound opens the interactive sound browser. Highlighting a sound plays
+// it, space stops or replays it, and Enter saves it.
+func pickSound() {
+	sounds, err := alert.ListValidSounds()
 	if err != nil {
-		fmt.Printf("Error updating %s configuration: %v\n", configType, err)
-		os.Exit(1)
-	} else {
-		fmt.Printf("%s configuration updated successfully.\n", configType)
-		os.Exit(0)
+		log.Printf("Error getting sounds: %v", err)
+		return
 	}
+	userSounds, err := alert.ListUserSounds()
+	if err != nil {
+		log.Printf("Error getting user sounds: %v", err)
+	}
+	sounds = append(sounds, userSounds...)
+
+	preview := &alert.Preview{}
+	picker := &display.Picker{
+		Title:    "Sounds",
+		Items:    sounds,
+		OnMove:   preview.Play,
+		Bindings: map[rune]func(string){' ': preview.Toggle},
+		Help:     "↑/↓ j/k move  space play/stop  / search  enter select  q quit",
+	}
+	sound, ok := picker.Run(Sound)
+	preview.Stop()
+	if !ok {
+		return
+	}
+	if err := UpdateConfig("sound", sound); err != nil {
+		fmt.Printf("Error updating sound configuration: %v\n", err)
+		return
+	}
+	fmt.Printf("Sound set to %s.\n", sound)
 }
 
-func isValidItem(newValue string, checker ValidItemChecker) bool {
+// printAudioDoctor probes every audio backend and reports which would be
+// used for the configured audio setting, along with the speech engine.
+func printAudioDoctor() {
+	fmt.Println("Audio backends:")
+	for _, backend := range alert.Backends() {
+		status := "ok"
+		if err := backend.Available(); err != nil {
+			status = "unavailable: " + err.Error()
+		}
+		fmt.Printf("  %-12s %s\n", backend.Name(), status)
+	}
 
-	//log.Printf("Entering isValidItem function with newValue:", newValue)
+	fmt.Printf("\nConfigured audio: %s\n", alert.AudioMode)
+	if selected, err := alert.SelectedBackend(); err != nil {
+		fmt.Println("No usable backend for this setting.")
+	} else {
+		fmt.Printf("Sounds will play with: %s\n", selected.Name())
+	}
 
-	validItems, err := checker()
-	if err != nil {
-		fmt.Printf("Error fetching list of valid items: %v\n", err)
-		os.Exit(2) // Exit with an error code indicating failure to fetch valid items
+	if engine, err := alert.SpeechEngine(); err != nil {
+		fmt.Printf("Speech: %v\n", err)
+	} else {
+		fmt.Printf("Speech: %s\n", engine)
 	}
+}
 
-	//log.Printf("Valid items fetched:", validItems)
+// validateFont accepts embedded and native fonts by name. Fonts on disk are
+// parsed in full so a broken file is caught before it is saved.
+func validateFont(font string) error {
+	if art.IsCustomFont(font) {
+		return art.ValidateFont(font)
+	}
+	return inList(listValidFonts)(font)
+}
 
-	for _, item := range validItems {
-		if item == newValue {
-			return true
+// inList adapts a ValidItemChecker into an ItemValidator.
+func inList(checker ValidItemChecker) ItemValidator {
+	return func(newValue string) error {
+		validItems, err := checker()
+		if err != nil {
+			return fmt.Errorf("fetching list of valid items: %w", err)
+		}
+		for _, item := range validItems {
+			if item == newValue {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not a valid item. Use -h to see how you can list valid items", newValue)
+	}
+}
+
+// validators check the keys whose values the config schema can't check on
+// its own.
+var validators = map[string]ItemValidator{
+	"display.font": validateFont,
+	"alerts.sound": alert.ValidateSound,
+}
+
+// runConfigCommand handles terminal-timer config set <key> <value>, which
+// saves a setting to the config file.
+func runConfigCommand(args []string) error {
+	if len(args) < 3 || args[0] != "set" {
+		return errors.New("usage: terminal-timer config set <key> <value>, for example: terminal-timer config set alerts.volume 80")
+	}
+	key, value := args[1], strings.Join(args[2:], " ")
+	if alias, ok := keyAliases[key]; ok {
+		key = alias
+	}
+
+	if validate, ok := validators[key]; ok {
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid %s: %v", key, err)
 		}
 	}
+	if err := UpdateConfig(key, value); err != nil {
+		return fmt.Errorf("error updating config: %v", err)
+	}
+	fmt.Printf("%s set to %s.\n", key, value)
+	return nil
+}
 
-	fmt.Printf("Invalid item specified. Please choose a valid item. Use -h to see how you can list valid items.\n")
-	os.Exit(2)
-	return false
+// ApplyOverrides switches to the font and sound given with -font and -sound
+// for this run only, leaving the config file as it is.
+func ApplyOverrides() error {
+	if *util.FontFlag != "" {
+		if err := validateFont(*util.FontFlag); err != nil {
+			return fmt.Errorf("invalid font: %v", err)
+		}
+		Font = *util.FontFlag
+	}
+	if *util.SoundFlag != "" {
+		if err := alert.ValidateSound(*util.SoundFlag); err != nil {
+			return fmt.Errorf("invalid sound: %v", err)
+		}
+		Sound = *util.SoundFlag
+	}
+	return nil
 }
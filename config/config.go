@@ -1,96 +1,319 @@
 package config
 
 import (
-	"bufio"
+	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/cameroncuttingedge/terminal-timer/alert"
+	"github.com/cameroncuttingedge/terminal-timer/art"
+	"github.com/cameroncuttingedge/terminal-timer/hooks"
 	"github.com/mitchellh/go-homedir"
 )
 
 //go:embed check/fonts.txt
 var fontsFile embed.FS
 
+// Current is the configuration loaded from the config file.
+var Current = Default()
+
+// Settings read from Current that the timer itself uses.
 var (
 	Font  string
 	Sound string
+	// SnoozePeriod is how long 's' on the end screen snoozes for.
+	SnoozePeriod = 5 * time.Minute
+	// ShowTitle puts the remaining time in the terminal title.
+	ShowTitle bool
 )
 
-const (
-	defaultFont  = ""
-	defaultSound = "Beeper.wav"
-)
+const configHeader = "# terminal-timer settings. See the README for what each key does.\n\n"
+
+// FieldError is a setting that failed validation. Line is where the key is
+// set in the config file, or 0 when that isn't known.
+type FieldError struct {
+	Key  string
+	Line int
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %v", e.Line, e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
 
 func LoadOrCreateConfig() error {
 	configPath := getConfigFilePath()
 
 	// Check if the config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// File does not exist, create it with default values
-		if err := SaveConfig(defaultFont, defaultSound, configPath); err != nil {
+		// Bring over an old timer-config.txt, or start from the defaults
+		if err := migrateLegacyConfig(configPath); err != nil {
 			return fmt.Errorf("failed to create default config file: %v", err)
 		}
 	}
 
+	alert.UserSoundDir = filepath.Join(filepath.Dir(configPath), "sounds")
+
 	// Load the configuration from the file
-	if err := LoadConfig(configPath); err != nil {
+	c, fieldErrs, err := readConfig(configPath)
+	if err != nil {
+		apply(Default())
 		return fmt.Errorf("failed to load config: %v", err)
 	}
+	Current = c
+	fieldErrs = append(fieldErrs, apply(c)...)
+	return configErrors(configPath, fieldErrs)
+}
+
+// readConfig decodes the config file over the defaults. Unknown keys are
+// reported as field errors; a file that isn't valid TOML is an error.
+func readConfig(filePath string) (Config, []*FieldError, error) {
+	c := Default()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return c, nil, err
+	}
+	meta, err := toml.Decode(string(data), &c)
+	if err != nil {
+		// The decoder's message already names the line.
+		return c, nil, fmt.Errorf("%s: %s", filePath, strings.TrimPrefix(err.Error(), "toml: "))
+	}
 
-	return nil
+	var fieldErrs []*FieldError
+	for _, key := range meta.Undecoded() {
+		fieldErrs = append(fieldErrs, &FieldError{Key: key.String(), Err: errors.New("unknown key")})
+	}
+	for _, fieldErr := range fieldErrs {
+		fieldErr.Line = keyLine(data, fieldErr.Key)
+	}
+	return c, fieldErrs, nil
+}
+
+// configErrors combines field errors into one error, filling in the line
+// numbers that are still missing.
+func configErrors(filePath string, fieldErrs []*FieldError) error {
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	data, _ := os.ReadFile(filePath)
+	messages := make([]string, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		if fieldErr.Line == 0 {
+			fieldErr.Line = keyLine(data, fieldErr.Key)
+		}
+	}
+	sort.SliceStable(fieldErrs, func(i, j int) bool { return fieldErrs[i].Line < fieldErrs[j].Line })
+	for i, fieldErr := range fieldErrs {
+		messages[i] = fieldErr.Error()
+	}
+	return fmt.Errorf("%s:\n  %s", filePath, strings.Join(messages, "\n  "))
 }
 
-func LoadConfig(filePath string) error {
-	file, err := os.Open(filePath)
+// apply validates c and hands its settings to the packages that use them.
+// A setting that fails validation keeps its default.
+func apply(c Config) []*FieldError {
+	var errs []*FieldError
+	fail := func(key string, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Key: key, Err: fmt.Errorf(format, args...)})
+	}
+	defaults := Default()
+
+	art.FontDir = fontDirectory(c.Display.FontDir)
+	Font = defaults.Display.Font
+	if c.Display.Font != "" {
+		if err := validateFont(c.Display.Font); err != nil {
+			fail("display.font", "%v", err)
+		} else {
+			Font = c.Display.Font
+		}
+	}
+	ShowTitle = c.Display.Title
+
+	Sound = defaults.Alerts.Sound
+	if c.Alerts.Sound != "" {
+		if err := alert.ValidateSound(c.Alerts.Sound); err != nil {
+			fail("alerts.sound", "%v", err)
+		} else {
+			Sound = c.Alerts.Sound
+		}
+	}
+	alert.AudioMode = alert.AudioAuto
+	switch {
+	case c.Alerts.Audio == "":
+	case alert.ValidAudioMode(c.Alerts.Audio):
+		alert.AudioMode = c.Alerts.Audio
+	default:
+		fail("alerts.audio", "unknown audio backend %q: run with -doctor to list the audio backends", c.Alerts.Audio)
+	}
+
+	alert.Volume = defaults.Alerts.Volume
+	if c.Alerts.Volume < 0 || c.Alerts.Volume > 100 {
+		fail("alerts.volume", "invalid volume %d: want a number from 0 to 100", c.Alerts.Volume)
+	} else {
+		alert.Volume = c.Alerts.Volume
+	}
+
+	durations := []struct {
+		key      string
+		value    Duration
+		target   *time.Duration
+		positive bool
+	}{
+		{"alerts.fadein", c.Alerts.FadeIn, &alert.FadeIn, false},
+		{"alerts.repeatgap", c.Alerts.RepeatGap, &alert.RepeatGap, false},
+		{"alerts.repeatmax", c.Alerts.RepeatMax, &alert.RepeatMax, false},
+		{"alerts.snooze", c.Alerts.Snooze, &SnoozePeriod, true},
+		{"alerts.webhooktimeout", c.Alerts.WebhookTimeout, &alert.WebhookTimeout, true},
+		{"hooks.timeout", c.Hooks.Timeout, &hooks.Timeout, true},
+	}
+	for _, d := range durations {
+		switch {
+		case d.positive && d.value <= 0:
+			fail(d.key, "invalid duration %v: want one longer than zero", time.Duration(d.value))
+		case d.value < 0:
+			fail(d.key, "invalid duration %v: want one of zero or more", time.Duration(d.value))
+		default:
+			*d.target = time.Duration(d.value)
+		}
+	}
+
+	channels, err := alert.ParseChannels(strings.Join(c.Alerts.Channels, ","))
 	if err != nil {
-		return err
+		fail("alerts.channels", "%v", err)
+		channels = alert.AutoChannels()
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			key := parts[0]
-			value := parts[1]
-			switch key {
-			case "font":
-				Font = value
-			case "sound":
-				Sound = value
-			}
+	alert.Active = channels
+
+	alert.Voice = c.Alerts.Voice
+	alert.SpeechRate = 0
+	if c.Alerts.SpeechRate < 0 {
+		fail("alerts.speechrate", "invalid speechrate %d: want words per minute, such as 175", c.Alerts.SpeechRate)
+	} else {
+		alert.SpeechRate = c.Alerts.SpeechRate
+	}
+
+	alert.Repeat = c.Alerts.Repeat
+
+	alert.Escalation = nil
+	for i, step := range c.Alerts.Escalate {
+		converted := alert.EscalationStep{
+			After:  time.Duration(step.After),
+			Sound:  step.Sound,
+			Volume: -1,
+			Bell:   step.Bell,
+			Notify: step.Notify,
 		}
+		if step.Volume != nil {
+			converted.Volume = *step.Volume
+		}
+		if err := converted.Validate(); err != nil {
+			fail(fmt.Sprintf("alerts.escalate[%d]", i), "%v", err)
+			continue
+		}
+		alert.Escalation = append(alert.Escalation, converted)
 	}
+	alert.SortEscalation(alert.Escalation)
 
-	if err := scanner.Err(); err != nil {
-		return err
+	alert.Warnings = nil
+	for _, warning := range c.Alerts.Warnings {
+		if warning <= 0 {
+			fail("alerts.warnings", "invalid warning %v: want durations such as \"5m\"", time.Duration(warning))
+			continue
+		}
+		alert.Warnings = append(alert.Warnings, time.Duration(warning))
 	}
+	sort.Slice(alert.Warnings, func(i, j int) bool { return alert.Warnings[i] > alert.Warnings[j] })
 
-	return nil
+	alert.WarningSound = defaults.Alerts.WarnSound
+	if c.Alerts.WarnSound != "" {
+		if err := alert.ValidateSound(c.Alerts.WarnSound); err != nil {
+			fail("alerts.warnsound", "%v", err)
+		} else {
+			alert.WarningSound = c.Alerts.WarnSound
+		}
+	}
+
+	alert.Webhooks = nil
+	for i, webhook := range c.Alerts.Webhooks {
+		converted := alert.Webhook{Format: webhook.Format, URL: webhook.URL}
+		if converted.Format == "" {
+			converted.Format = "json"
+		}
+		if err := converted.Validate(); err != nil {
+			fail(fmt.Sprintf("alerts.webhooks[%d]", i), "%v", err)
+			continue
+		}
+		alert.Webhooks = append(alert.Webhooks, converted)
+	}
+
+	for _, event := range hooks.Events {
+		hooks.Commands[event] = *c.Hooks.command(event)
+	}
+
+	for name, preset := range c.Presets {
+		if err := validatePresetName(name); err != nil {
+			fail("presets."+name, "%v", err)
+		} else if err := validatePreset(preset); err != nil {
+			fail("presets."+name, "%v", err)
+		}
+	}
+
+	return errs
+}
+
+// command returns the field holding the command for event.
+func (h *HooksConfig) command(event hooks.Event) *string {
+	switch event {
+	case hooks.Start:
+		return &h.Start
+	case hooks.Warning:
+		return &h.Warning
+	case hooks.Expire:
+		return &h.Expire
+	case hooks.Acknowledge:
+		return &h.Acknowledge
+	default:
+		return &h.Quit
+	}
 }
 
-// SaveConfig writes the provided font and sound values to the specified file path.
-func SaveConfig(font, sound, filePath string) error {
-	content := fmt.Sprintf("font=%s\nsound=%s", font, sound)
+// SaveConfig writes c to the specified file path.
+func SaveConfig(filePath string, c Config) error {
+	var buf bytes.Buffer
+	buf.WriteString(configHeader)
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(c); err != nil {
+		return err
+	}
 	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, []byte(content), 0644)
+	return os.WriteFile(filePath, buf.Bytes(), 0644)
 }
 
 func getConfigFilePath() string {
+	return filepath.Join(configDirectory(), "timer-config.toml")
+}
+
+func configDirectory() string {
 	homeDir, err := homedir.Dir()
 	if err != nil {
 		panic(err)
 	}
 
-	filename := "timer-config.txt"
-	var dbPath string
+	var dir string
 
 	appName := "timer"
 
@@ -100,10 +323,10 @@ func getConfigFilePath() string {
 		if appDataDir == "" {
 			appDataDir = filepath.Join(homeDir, "AppData", "Local")
 		}
-		dbPath = filepath.Join(appDataDir, appName, filename)
+		dir = filepath.Join(appDataDir, appName)
 
 	case "darwin":
-		dbPath = filepath.Join(homeDir, "Library", "Application Support", appName, filename)
+		dir = filepath.Join(homeDir, "Library", "Application Support", appName)
 
 	default:
 		xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
@@ -111,40 +334,216 @@ func getConfigFilePath() string {
 			//if not xdgConfigHome the use /home/$USER/.confg
 			xdgConfigHome = filepath.Join(homeDir, ".config")
 		}
-		dbPath = filepath.Join(xdgConfigHome, appName, filename)
+		dir = filepath.Join(xdgConfigHome, appName)
 	}
 
-	if err := os.MkdirAll(filepath.Dir(dbPath), 0700); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		panic(err)
 	}
 
-	return dbPath
+	return dir
+}
+
+// keyAliases maps the short names from the old config file, which config
+// set and the pickers still accept, to their keys.
+var keyAliases = map[string]string{
+	"font":           "display.font",
+	"fontdir":        "display.fontdir",
+	"title":          "display.title",
+	"alerts":         "alerts.channels",
+	"sound":          "alerts.sound",
+	"audio":          "alerts.audio",
+	"volume":         "alerts.volume",
+	"fadein":         "alerts.fadein",
+	"repeat":         "alerts.repeat",
+	"repeatgap":      "alerts.repeatgap",
+	"repeatmax":      "alerts.repeatmax",
+	"snooze":         "alerts.snooze",
+	"warnings":       "alerts.warnings",
+	"warnsound":      "alerts.warnsound",
+	"voice":          "alerts.voice",
+	"speechrate":     "alerts.speechrate",
+	"webhooktimeout": "alerts.webhooktimeout",
+	"hooktimeout":    "hooks.timeout",
 }
 
-// Assumes that the sound or font has been validated
+// UpdateConfig sets key, such as alerts.volume or one of the short names in
+// keyAliases, to value and saves the config file. value is read as a TOML
+// value where it is one, and as a string otherwise.
 func UpdateConfig(key, value string) error {
+	if alias, ok := keyAliases[key]; ok {
+		key = alias
+	}
 	configPath := getConfigFilePath()
 
-	err := LoadConfig(configPath)
+	c, _, err := readConfig(configPath)
 	if err != nil {
 		return err
 	}
-
-	switch key {
-	case "font":
-		Font = value
-	case "sound":
-		Sound = value
-	default:
-		return fmt.Errorf("invalid configuration key: %s", key)
+	updated, err := setKey(c, key, value)
+	if err != nil {
+		return err
+	}
+	// Only complain about the key being set, not problems elsewhere.
+	for _, fieldErr := range apply(updated) {
+		if fieldErr.Key == key || strings.HasPrefix(fieldErr.Key, key+".") || strings.HasPrefix(fieldErr.Key, key+"[") {
+			apply(c)
+			return fieldErr
+		}
 	}
+	Current = updated
 
 	// Save the updated configuration
-	return SaveConfig(Font, Sound, configPath)
+	return SaveConfig(configPath, updated)
+}
+
+// setKey returns c with the dotted key set to value. It round trips through
+// a generic map so any key in the schema can be set.
+func setKey(c Config, key, value string) (Config, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+		return c, err
+	}
+	var raw map[string]interface{}
+	if _, err := toml.Decode(buf.String(), &raw); err != nil {
+		return c, err
+	}
+
+	path := strings.Split(key, ".")
+	table := raw
+	for _, name := range path[:len(path)-1] {
+		next, ok := table[name].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			table[name] = next
+		}
+		table = next
+	}
+	table[path[len(path)-1]] = parseValue(value)
+
+	buf.Reset()
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return c, err
+	}
+	var updated Config
+	meta, err := toml.Decode(buf.String(), &updated)
+	if err != nil {
+		return c, &FieldError{Key: key, Err: fmt.Errorf("%q is the wrong type of value", value)}
+	}
+	if len(meta.Undecoded()) > 0 {
+		return c, &FieldError{Key: key, Err: errors.New("unknown key")}
+	}
+	return updated, nil
+}
+
+// parseValue reads value as a TOML value, such as 80, true or ["5m", "1m"],
+// falling back to a plain string.
+func parseValue(value string) interface{} {
+	var doc struct{ V interface{} }
+	if _, err := toml.Decode("v = "+value, &doc); err == nil {
+		return doc.V
+	}
+	return value
+}
+
+// keyLine finds the line a dotted key such as alerts.volume is set on, or the
+// header of the table for keys such as alerts.escalate[1]. It returns 0 if
+// the key isn't in the file.
+func keyLine(data []byte, key string) int {
+	lines := strings.Split(string(data), "\n")
+
+	if open := strings.Index(key, "["); open >= 0 {
+		table := key[:open]
+		var index int
+		fmt.Sscanf(key[open:], "[%d]", &index)
+		seen := 0
+		for i, line := range lines {
+			if strings.ReplaceAll(strings.TrimSpace(line), " ", "") == "[["+table+"]]" {
+				if seen == index {
+					return i + 1
+				}
+				seen++
+			}
+		}
+		return 0
+	}
+
+	section, name := "", key
+	if dot := strings.LastIndex(key, "."); dot >= 0 {
+		section, name = key[:dot], key[dot+1:]
+	}
+	current := ""
+	sectionLine := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			current = strings.Trim(trimmed, "[] ")
+			if current == key {
+				return i + 1
+			}
+			if current == section && sectionLine == 0 {
+				sectionLine = i + 1
+			}
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if eq := strings.Index(trimmed, "="); eq > 0 && strings.Trim(strings.TrimSpace(trimmed[:eq]), `"`) == name {
+			return i + 1
+		}
+	}
+	return sectionLine
+}
+
+// fontDirectory is where custom FIGlet fonts are looked up. It defaults to a
+// fonts directory next to the config file.
+func fontDirectory(fontDir string) string {
+	if fontDir == "" {
+		return filepath.Join(configDirectory(), "fonts")
+	}
+	dir, err := homedir.Expand(fontDir)
+	if err != nil {
+		return fontDir
+	}
+	return dir
 }
 
 func PrintCurrentConfig() {
 	fmt.Println("Current Configuration:")
+	fmt.Printf("Config File: %s\n", getConfigFilePath())
 	fmt.Printf("Font: %s\n", Font)
 	fmt.Printf("Sound: %s\n", Sound)
+	fmt.Printf("Font Directory: %s\n", art.FontDir)
+	fmt.Printf("Sound Directory: %s\n", alert.UserSoundDir)
+	fmt.Printf("Audio: %s\n", alert.AudioMode)
+	fmt.Printf("Volume: %d\n", alert.Volume)
+	fmt.Printf("Fade-in: %v\n", alert.FadeIn)
+	fmt.Printf("Title: %t\n", ShowTitle)
+	fmt.Printf("Alerts: %s\n", alert.Active)
+	if alert.Active.Speech {
+		fmt.Printf("Voice: %q at %d words per minute (0 for the default)\n", alert.Voice, alert.SpeechRate)
+	}
+	fmt.Printf("Repeat: %t (gap %v, max %v)\n", alert.Repeat, alert.RepeatGap, alert.RepeatMax)
+	fmt.Printf("Snooze: %v\n", SnoozePeriod)
+	if len(alert.Warnings) > 0 {
+		fmt.Printf("Warnings: %v (%s)\n", alert.Warnings, alert.WarningSound)
+	}
+	for _, step := range alert.Escalation {
+		fmt.Printf("Escalate after %v: sound %q, volume %d, bell %t, notify %t\n", step.After, step.Sound, step.Volume, step.Bell, step.Notify)
+	}
+	for _, webhook := range alert.Webhooks {
+		fmt.Printf("Webhook: %s %s\n", webhook.Format, webhook.URL)
+	}
+	if len(Current.Presets) > 0 {
+		names := make([]string, 0, len(Current.Presets))
+		for name := range Current.Presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("Presets: %s\n", strings.Join(names, ", "))
+	}
+	if described := hooks.Describe(); described != "" {
+		fmt.Printf("Hooks (timeout %v):\n%s", hooks.Timeout, described)
+	}
 }
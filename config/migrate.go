@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cameroncuttingedge/terminal-timer/alert"
+	"github.com/cameroncuttingedge/terminal-timer/hooks"
+)
+
+// legacyConfigName is the key=value file used before the config moved to TOML.
+const legacyConfigName = "timer-config.txt"
+
+// migrateLegacyConfig writes a new config file at configPath, carrying over
+// the settings from a timer-config.txt next to it if there is one. The old
+// file is kept with a .bak suffix.
+func migrateLegacyConfig(configPath string) error {
+	legacyPath := filepath.Join(filepath.Dir(configPath), legacyConfigName)
+	values, err := readLegacyConfig(legacyPath)
+	if os.IsNotExist(err) {
+		return SaveConfig(configPath, Default())
+	}
+	if err != nil {
+		return err
+	}
+
+	c := Default()
+	for key, value := range values {
+		if err := convertLegacySetting(&c, key, value); err != nil {
+			log.Printf("Skipping %s from %s: %v", key, legacyPath, err)
+		}
+	}
+	if err := SaveConfig(configPath, c); err != nil {
+		return err
+	}
+	if err := os.Rename(legacyPath, legacyPath+".bak"); err != nil {
+		log.Printf("Error renaming %s: %v", legacyPath, err)
+	}
+	log.Printf("Migrated %s to %s", legacyPath, configPath)
+	return nil
+}
+
+// readLegacyConfig reads the key=value lines of an old config file, skipping
+// empty values.
+func readLegacyConfig(filePath string) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) == 2 && parts[1] != "" {
+			values[parts[0]] = parts[1]
+		}
+	}
+	return values, scanner.Err()
+}
+
+// convertLegacySetting stores one old key=value setting in c.
+func convertLegacySetting(c *Config, key, value string) error {
+	var err error
+	switch key {
+	case "font":
+		c.Display.Font = value
+	case "fontdir":
+		c.Display.FontDir = value
+	case "title":
+		c.Display.Title, err = strconv.ParseBool(value)
+	case "sound":
+		c.Alerts.Sound = value
+	case "audio":
+		c.Alerts.Audio = value
+	case "volume":
+		c.Alerts.Volume, err = strconv.Atoi(value)
+	case "fadein":
+		err = legacyDuration(&c.Alerts.FadeIn, value)
+	case "alerts":
+		c.Alerts.Channels = nil
+		for _, channel := range strings.Split(value, ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				c.Alerts.Channels = append(c.Alerts.Channels, channel)
+			}
+		}
+	case "voice":
+		c.Alerts.Voice = value
+	case "speechrate":
+		c.Alerts.SpeechRate, err = strconv.Atoi(value)
+	case "repeat":
+		c.Alerts.Repeat, err = strconv.ParseBool(value)
+	case "repeatgap":
+		err = legacyDuration(&c.Alerts.RepeatGap, value)
+	case "repeatmax":
+		err = legacyDuration(&c.Alerts.RepeatMax, value)
+	case "snooze":
+		err = legacyDuration(&c.Alerts.Snooze, value)
+	case "warnings":
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			var warning Duration
+			if err = legacyDuration(&warning, field); err != nil {
+				break
+			}
+			c.Alerts.Warnings = append(c.Alerts.Warnings, warning)
+		}
+	case "warnsound":
+		c.Alerts.WarnSound = value
+	case "webhooktimeout":
+		err = legacyDuration(&c.Alerts.WebhookTimeout, value)
+	case "escalate":
+		var steps []alert.EscalationStep
+		if steps, err = alert.ParseEscalation(value); err == nil {
+			for _, step := range steps {
+				converted := EscalationStep{After: Duration(step.After), Sound: step.Sound, Bell: step.Bell, Notify: step.Notify}
+				if step.Volume >= 0 {
+					volume := step.Volume
+					converted.Volume = &volume
+				}
+				c.Alerts.Escalate = append(c.Alerts.Escalate, converted)
+			}
+		}
+	case "webhooks":
+		var webhooks []alert.Webhook
+		if webhooks, err = alert.ParseWebhooks(value); err == nil {
+			for _, webhook := range webhooks {
+				c.Alerts.Webhooks = append(c.Alerts.Webhooks, WebhookConfig{URL: webhook.URL, Format: webhook.Format})
+			}
+		}
+	case "hooktimeout":
+		err = legacyDuration(&c.Hooks.Timeout, value)
+	default:
+		event := hooks.Event(strings.TrimPrefix(key, "on"))
+		for _, known := range hooks.Events {
+			if strings.HasPrefix(key, "on") && event == known {
+				*c.Hooks.command(event) = value
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown key")
+	}
+	return err
+}
+
+func legacyDuration(d *Duration, value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
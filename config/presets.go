@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cameroncuttingedge/terminal-timer/alert"
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// reservedNames are the command words that can't be used as preset names.
+var reservedNames = []string{"preset", "config"}
+
+// UsePreset looks up the preset called name and, if there is one, switches
+// to its font and sound for this run without saving them.
+func UsePreset(name string) (Preset, bool) {
+	preset, ok := Current.Presets[name]
+	// A broken preset has already been reported when the config was loaded.
+	if !ok || validatePreset(preset) != nil {
+		return Preset{}, false
+	}
+	if preset.Font != "" {
+		Font = preset.Font
+	}
+	if preset.Sound != "" {
+		Sound = preset.Sound
+	}
+	return preset, true
+}
+
+// runPresetCommand handles terminal-timer preset list, add and remove.
+func runPresetCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: terminal-timer preset list | add <name> <duration> [-r reminder] [-f font] [-s sound] | remove <name>")
+	}
+	switch args[0] {
+	case "list":
+		listPresets()
+		return nil
+	case "add":
+		return addPreset(args[1:])
+	case "remove", "rm":
+		if len(args) != 2 {
+			return errors.New("usage: terminal-timer preset remove <name>")
+		}
+		return removePreset(args[1])
+	default:
+		return fmt.Errorf("unknown preset command %q: want list, add or remove", args[0])
+	}
+}
+
+func listPresets() {
+	if len(Current.Presets) == 0 {
+		fmt.Println("No presets yet. Add one with: terminal-timer preset add standup 15m -r \"Wrap up standup\"")
+		return
+	}
+	names := make([]string, 0, len(Current.Presets))
+	for name := range Current.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDURATION\tREMINDER\tFONT\tSOUND")
+	for _, name := range names {
+		preset := Current.Presets[name]
+		fmt.Fprintf(w, "%s\t%v\t%s\t%s\t%s\n", name, util.FormatShort(time.Duration(preset.Duration)), preset.Reminder, preset.Font, preset.Sound)
+	}
+	w.Flush()
+}
+
+// addPreset saves a preset from arguments such as
+// standup 15m -r "Wrap up standup" -s Polite.wav. Flags may come before or
+// after the name and duration.
+func addPreset(args []string) error {
+	flags := flag.NewFlagSet("preset add", flag.ContinueOnError)
+	reminder := flags.String("r", "", "Reminder message")
+	font := flags.String("f", "", "Font for this preset")
+	sound := flags.String("s", "", "Sound for this preset")
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) != 2 {
+		return errors.New("usage: terminal-timer preset add <name> <duration> [-r reminder] [-f font] [-s sound]")
+	}
+	name := positional[0]
+	if err := validatePresetName(name); err != nil {
+		return err
+	}
+
+	duration, err := time.ParseDuration(positional[1])
+	if err != nil || duration < time.Second {
+		return fmt.Errorf("invalid duration %q: want one such as 15m or 1h30m", positional[1])
+	}
+	preset := Preset{Duration: Duration(duration), Reminder: *reminder, Font: *font, Sound: *sound}
+	if err := validatePreset(preset); err != nil {
+		return err
+	}
+	if preset.Font != "" {
+		if err := validateFont(preset.Font); err != nil {
+			return fmt.Errorf("invalid font: %v", err)
+		}
+	}
+
+	err = updatePresets(func(presets map[string]Preset) error {
+		presets[name] = preset
+		return nil
+	})
+	if err == nil {
+		fmt.Printf("Preset %s saved, start it with: terminal-timer %s\n", name, name)
+	}
+	return err
+}
+
+func removePreset(name string) error {
+	err := updatePresets(func(presets map[string]Preset) error {
+		if _, ok := presets[name]; !ok {
+			return fmt.Errorf("no preset named %q", name)
+		}
+		delete(presets, name)
+		return nil
+	})
+	if err == nil {
+		fmt.Printf("Preset %s removed.\n", name)
+	}
+	return err
+}
+
+// updatePresets changes the presets in the config file with change and saves
+// it.
+func updatePresets(change func(map[string]Preset) error) error {
+	configPath := getConfigFilePath()
+	c, _, err := readConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if c.Presets == nil {
+		c.Presets = make(map[string]Preset)
+	}
+	if err := change(c.Presets); err != nil {
+		return err
+	}
+	Current.Presets = c.Presets
+	return SaveConfig(configPath, c)
+}
+
+// validatePresetName rejects names that would be read as a duration or a
+// command instead of a preset.
+func validatePresetName(name string) error {
+	if name == "" || strings.ContainsAny(name, ": \t") {
+		return fmt.Errorf("invalid preset name %q: want a single word without a colon", name)
+	}
+	for _, reserved := range reservedNames {
+		if name == reserved {
+			return fmt.Errorf("invalid preset name %q: it is a command", name)
+		}
+	}
+	return nil
+}
+
+// validatePreset checks a preset has a duration and, if it sets one, a known
+// sound. Fonts are checked when a preset is added, as that can mean parsing a
+// font file.
+func validatePreset(preset Preset) error {
+	if preset.Duration < Duration(time.Second) {
+		return errors.New("want a duration of at least a second, such as \"15m\"")
+	}
+	if preset.Sound != "" {
+		if err := alert.ValidateSound(preset.Sound); err != nil {
+			return err
+		}
+	}
+	return nil
+}
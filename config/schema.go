@@ -0,0 +1,113 @@
+package config
+
+import (
+	"time"
+
+	"github.com/cameroncuttingedge/terminal-timer/alert"
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// Config is the layout of the config file, one table per section.
+type Config struct {
+	Display DisplayConfig     `toml:"display"`
+	Alerts  AlertsConfig      `toml:"alerts"`
+	Hooks   HooksConfig       `toml:"hooks"`
+	Presets map[string]Preset `toml:"presets,omitempty"`
+}
+
+// DisplayConfig is the [display] section.
+type DisplayConfig struct {
+	Font    string `toml:"font"`
+	FontDir string `toml:"fontdir"`
+	Title   bool   `toml:"title"`
+}
+
+// AlertsConfig is the [alerts] section.
+type AlertsConfig struct {
+	Channels       []string         `toml:"channels"`
+	Sound          string           `toml:"sound"`
+	Audio          string           `toml:"audio"`
+	Volume         int              `toml:"volume"`
+	FadeIn         Duration         `toml:"fadein"`
+	Repeat         bool             `toml:"repeat"`
+	RepeatGap      Duration         `toml:"repeatgap"`
+	RepeatMax      Duration         `toml:"repeatmax"`
+	Snooze         Duration         `toml:"snooze"`
+	Warnings       []Duration       `toml:"warnings"`
+	WarnSound      string           `toml:"warnsound"`
+	Voice          string           `toml:"voice"`
+	SpeechRate     int              `toml:"speechrate"`
+	WebhookTimeout Duration         `toml:"webhooktimeout"`
+	Escalate       []EscalationStep `toml:"escalate,omitempty"`
+	Webhooks       []WebhookConfig  `toml:"webhooks,omitempty"`
+}
+
+// EscalationStep is one [[alerts.escalate]] table.
+type EscalationStep struct {
+	After  Duration `toml:"after"`
+	Sound  string   `toml:"sound,omitempty"`
+	Volume *int     `toml:"volume,omitempty"`
+	Bell   bool     `toml:"bell,omitempty"`
+	Notify bool     `toml:"notify,omitempty"`
+}
+
+// WebhookConfig is one [[alerts.webhooks]] table.
+type WebhookConfig struct {
+	URL    string `toml:"url"`
+	Format string `toml:"format,omitempty"`
+}
+
+// HooksConfig is the [hooks] section, holding a command for each event.
+type HooksConfig struct {
+	Timeout     Duration `toml:"timeout"`
+	Start       string   `toml:"start,omitempty"`
+	Warning     string   `toml:"warning,omitempty"`
+	Expire      string   `toml:"expire,omitempty"`
+	Acknowledge string   `toml:"acknowledge,omitempty"`
+	Quit        string   `toml:"quit,omitempty"`
+}
+
+// Preset is a named timer under [presets], started with terminal-timer <name>.
+// Font and Sound replace the configured ones for that timer only.
+type Preset struct {
+	Duration Duration `toml:"duration"`
+	Reminder string   `toml:"reminder,omitempty"`
+	Font     string   `toml:"font,omitempty"`
+	Sound    string   `toml:"sound,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "2s" or "5m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(util.FormatShort(time.Duration(d))), nil
+}
+
+// Default returns the settings used for anything the config file leaves out.
+func Default() Config {
+	return Config{
+		Alerts: AlertsConfig{
+			Channels:       []string{alert.ChannelsAuto},
+			Sound:          "Beeper.wav",
+			Audio:          alert.AudioAuto,
+			Volume:         100,
+			RepeatGap:      Duration(2 * time.Second),
+			RepeatMax:      Duration(5 * time.Minute),
+			Snooze:         Duration(5 * time.Minute),
+			WarnSound:      "Taptap.wav",
+			WebhookTimeout: Duration(10 * time.Second),
+		},
+		Hooks: HooksConfig{
+			Timeout: Duration(10 * time.Second),
+		},
+	}
+}
@@ -1,10 +1,13 @@
 package display
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"os"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cameroncuttingedge/terminal-timer/art"
 	"github.com/cameroncuttingedge/terminal-timer/util"
@@ -14,6 +17,8 @@ type DisplayMatrix struct {
 	Width  int
 	Height int
 	Matrix [][]rune
+
+	out bytes.Buffer
 }
 
 func NewDisplayMatrix(width, height int) *DisplayMatrix {
@@ -37,30 +42,17 @@ func (dm *DisplayMatrix) AddCenteredMessage(message string) {
 	}
 
 	for i, line := range lines {
-		maxWidth := len(line)
+		maxWidth := utf8.RuneCountInString(line)
 		startX := (dm.Width - maxWidth) / 2
 		if startX < 0 {
 			startX = 0
 		}
-
-		for x, char := range line {
-			matrixY := startY + i
-			matrixX := startX + x
-			if matrixY < dm.Height && matrixX < dm.Width {
-				dm.Matrix[matrixY][matrixX] = char
-			}
-		}
+		dm.AddText(startX, startY+i, line)
 	}
 }
 
 func (dm *DisplayMatrix) AddCenteredAsciiArt(asciiArt []string, message string) {
-	artHeight := len(asciiArt)
-	artWidth := 0
-	for _, line := range asciiArt {
-		if len(line) > artWidth {
-			artWidth = len(line)
-		}
-	}
+	artWidth, artHeight := art.Size(asciiArt)
 
 	// Check if the art will fit vertically and horizontally
 	if artHeight > dm.Height || artWidth > dm.Width {
@@ -72,45 +64,66 @@ func (dm *DisplayMatrix) AddCenteredAsciiArt(asciiArt []string, message string)
 	startX := (dm.Width - artWidth) / 2
 
 	for y, line := range asciiArt {
-		for x, char := range line {
+		x := 0
+		for _, char := range line {
 			matrixY := startY + y
 			matrixX := startX + x
 			if matrixY < dm.Height && matrixX < dm.Width {
 				dm.Matrix[matrixY][matrixX] = char
 			}
+			x++
 		}
 	}
 }
 
+// AddFittedAsciiArt centers message rendered in the largest font that fits the
+// current matrix, starting with the given font. When nothing fits the plain
+// message is shown instead.
+func (dm *DisplayMatrix) AddFittedAsciiArt(message, font string) {
+	asciiArt := art.FitAsciiArt(message, font, dm.Width, dm.Height)
+	if asciiArt == nil {
+		dm.AddCenteredMessage(message)
+		return
+	}
+	dm.AddCenteredAsciiArt(asciiArt, message)
+}
+
 func (dm *DisplayMatrix) Print() {
-	var output strings.Builder
+	dm.out.Reset()
 	for _, row := range dm.Matrix {
 		for _, char := range row {
-			output.WriteRune(char)
+			dm.out.WriteRune(char)
 		}
-		output.WriteRune('\n')
+		dm.out.WriteByte('\n')
 	}
 	util.HideCursor()
 	util.Clear()
-	fmt.Print(output.String())
+	os.Stdout.Write(dm.out.Bytes())
 }
 
+// Clear blanks the matrix in place without reallocating it.
+func (dm *DisplayMatrix) Clear() {
+	for _, row := range dm.Matrix {
+		for j := range row {
+			row[j] = ' '
+		}
+	}
+}
+
+// ResizeAndClear picks up the current terminal size and blanks the matrix.
+// The existing buffers are reused when the size has not changed.
 func (dm *DisplayMatrix) ResizeAndClear() {
 	width, height, err := util.GetSize()
 	if err != nil {
 		fmt.Println("Error getting terminal size:", err)
 		return
 	}
-	newMatrix := make([][]rune, height)
-	for i := range newMatrix {
-		newMatrix[i] = make([]rune, width)
-		for j := range newMatrix[i] {
-			newMatrix[i][j] = ' '
-		}
+	if width == dm.Width && height == dm.Height {
+		dm.Clear()
+		return
 	}
-	dm.Width = width
-	dm.Height = height
-	dm.Matrix = newMatrix
+	resized := NewDisplayMatrix(width, height)
+	dm.Width, dm.Height, dm.Matrix = resized.Width, resized.Height, resized.Matrix
 }
 
 func (dm *DisplayMatrix) AddBottomLeftMessage(message string) {
@@ -118,36 +131,63 @@ func (dm *DisplayMatrix) AddBottomLeftMessage(message string) {
 	totalLines := len(lines)
 
 	startY := dm.Height - totalLines
-	startX := 0
-
 	for i, line := range lines {
-		for x, char := range line {
-			matrixY := startY + i
-			matrixX := startX + x
-			if matrixY >= 0 && matrixY < dm.Height && matrixX >= 0 && matrixX < dm.Width {
-				dm.Matrix[matrixY][matrixX] = char
-			}
-			if matrixX >= dm.Width-1 {
-				break
-			}
+		dm.AddText(0, startY+i, line)
+	}
+}
+
+// AddText writes a single line of text starting at x, y, clipped to the matrix.
+func (dm *DisplayMatrix) AddText(x, y int, text string) {
+	if y < 0 || y >= dm.Height {
+		return
+	}
+	for _, char := range text {
+		if x >= dm.Width {
+			return
 		}
-		if startY+i >= dm.Height-1 {
-			break
+		if x >= 0 {
+			dm.Matrix[y][x] = char
 		}
+		x++
+	}
+}
+
+// Blit copies src into the matrix with its top left corner at x, y.
+func (dm *DisplayMatrix) Blit(src *DisplayMatrix, x, y int) {
+	for row, line := range src.Matrix {
+		dm.AddText(x, y+row, string(line))
 	}
 }
 
-func BufferEndMessage(matrix *DisplayMatrix, reminder string, font string) {
-	matrix.ResizeAndClear()
-	timeUpMessage := art.GetAsciiArt(reminder, font)
-	matrix.AddCenteredAsciiArt(timeUpMessage, reminder)
-	message := "Press 'q' to quit or 'r' to repeat."
+// BufferEndMessage shows the reminder along with the quit, repeat and snooze
+// keys. snoozes is how many times this reminder has been snoozed.
+func BufferEndMessage(matrix *DisplayMatrix, reminder string, font string, snooze time.Duration, snoozes int) {
+	matrix.Clear()
+	matrix.AddFittedAsciiArt(reminder, font)
+	message := fmt.Sprintf("Press 'q' to quit, 'r' to repeat or 's' to snooze %s (1/5/0: 1/5/10m).", util.FormatShort(snooze))
+	if snoozes > 0 {
+		message += " " + SnoozeCount(snoozes)
+	}
 	matrix.AddBottomLeftMessage(message)
 }
 
+// SnoozeCount describes how many times a reminder was snoozed.
+func SnoozeCount(snoozes int) string {
+	if snoozes == 1 {
+		return "Snoozed once."
+	}
+	return fmt.Sprintf("Snoozed %d times.", snoozes)
+}
+
 func (dm *DisplayMatrix) PrintItemsInGrid(items []string, columns int) {
-	// Calculate necessary dimensions
 	rows := int(math.Ceil(float64(len(items)) / float64(columns)))
+	dm.addGrid(items, columns, (dm.Height-rows)/2)
+}
+
+// addGrid lays items out in columns with the first row at startY, centered
+// horizontally.
+func (dm *DisplayMatrix) addGrid(items []string, columns, startY int) {
+	// Calculate necessary dimensions
 	maxItemLength := 0
 	for _, item := range items {
 		if len(item) > maxItemLength {
@@ -158,7 +198,6 @@ func (dm *DisplayMatrix) PrintItemsInGrid(items []string, columns int) {
 	// Calculate starting positions
 	totalWidth := maxItemLength*columns + (columns-1)*2 // Assuming 2 spaces as padding between columns
 	startX := (dm.Width - totalWidth) / 2
-	startY := (dm.Height - rows) / 2
 
 	// Iterate over items and print them in the specified grid layout
 	for i, item := range items {
@@ -174,6 +213,21 @@ func (dm *DisplayMatrix) PrintItemsInGrid(items []string, columns int) {
 	}
 }
 
+// gridColumns returns how many columns of items fit in width.
+func gridColumns(items []string, width int) int {
+	maxLen := 0
+	for _, item := range items {
+		if len(item) > maxLen {
+			maxLen = len(item)
+		}
+	}
+	columns := int(math.Floor(float64(width) / float64(maxLen+2))) // 2 spaces padding
+	if columns < 1 {
+		columns = 1
+	}
+	return columns
+}
+
 func RenderPrettyData(fonts []string) {
 	width, height, err := util.GetSize()
 	if err != nil {
@@ -183,13 +237,7 @@ func RenderPrettyData(fonts []string) {
 	matrix := NewDisplayMatrix(width, height)
 
 	// Determine the number of columns based on the terminal width
-	maxLen := 0
-	for _, font := range fonts {
-		if len(font) > maxLen {
-			maxLen = len(font)
-		}
-	}
-	columns := int(math.Floor(float64(width) / float64(maxLen+2))) // 2 spaces padding
+	columns := gridColumns(fonts, width)
 
 	// Use the DisplayMatrix to print the fonts in a grid
 	matrix.PrintItemsInGrid(fonts, columns)
@@ -198,22 +246,39 @@ func RenderPrettyData(fonts []string) {
 	os.Exit(1)
 }
 
-func RenderFontExample(font string) {
-
-	message := "420:69"
+// DataGroup is a titled list of items for RenderGroupedData.
+type DataGroup struct {
+	Title string
+	Items []string
+}
 
-	width, height, err := util.GetSize()
+// RenderGroupedData prints each non-empty group as a titled grid, one below
+// the other.
+func RenderGroupedData(groups []DataGroup) {
+	width, _, err := util.GetSize()
 	if err != nil {
 		fmt.Printf("Error getting terminal size: %v\n", err)
 		return
 	}
-	matrix := NewDisplayMatrix(width, height)
 
-	art := art.GetAsciiArt(message, font)
-
-	matrix.AddCenteredAsciiArt(art, message)
+	height := 0
+	for _, group := range groups {
+		if len(group.Items) > 0 {
+			columns := gridColumns(group.Items, width)
+			height += 2 + int(math.Ceil(float64(len(group.Items))/float64(columns)))
+		}
+	}
+	matrix := NewDisplayMatrix(width, height)
 
+	y := 0
+	for _, group := range groups {
+		if len(group.Items) == 0 {
+			continue
+		}
+		columns := gridColumns(group.Items, width)
+		matrix.AddText(0, y, group.Title)
+		matrix.addGrid(group.Items, columns, y+1)
+		y += 2 + int(math.Ceil(float64(len(group.Items))/float64(columns)))
+	}
 	matrix.Print()
-
-	os.Exit(1)
 }
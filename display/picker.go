@@ -0,0 +1,301 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"unicode"
+
+	"github.com/cameroncuttingedge/terminal-timer/art"
+	"github.com/cameroncuttingedge/terminal-timer/util"
+
+	"github.com/mattn/go-tty"
+)
+
+// Picker is an interactive list with vim-style navigation, fuzzy search and a
+// preview pane next to the list.
+type Picker struct {
+	Title   string
+	Items   []string
+	Preview func(matrix *DisplayMatrix, item string)
+
+	// OnMove is called whenever a different item becomes highlighted.
+	OnMove func(item string)
+	// Bindings adds keys that act on the highlighted item outside of search.
+	Bindings map[rune]func(item string)
+	// Help replaces the default key summary at the bottom of the screen.
+	Help string
+
+	highlighted string
+	filtered    []string
+	cursor      int
+	offset      int
+	query       string
+	searching   bool
+}
+
+const pickerHelp = "↑/↓ j/k move  / search  enter select  q quit"
+
+// Run shows the picker with current highlighted and blocks until an item is
+// chosen or the picker is dismissed, in which case ok is false.
+func (p *Picker) Run(current string) (choice string, ok bool) {
+	t, err := tty.Open()
+	if err != nil {
+		fmt.Printf("failed to open tty: %v\n", err)
+		return "", false
+	}
+	defer t.Close()
+
+	keys := make(chan rune)
+	go func() {
+		for {
+			key, err := util.ReadKey(t)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- key
+		}
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	width, height, err := util.GetSize()
+	if err != nil {
+		fmt.Printf("Error getting terminal size: %v\n", err)
+		return "", false
+	}
+	matrix := NewDisplayMatrix(width, height)
+	resized := util.WatchResize()
+
+	p.filter()
+	for i, item := range p.filtered {
+		if item == current {
+			p.cursor = i
+		}
+	}
+
+	util.HideCursor()
+	util.Render()
+	defer util.Cleanup(true)
+
+	for {
+		p.notifyMove()
+		p.draw(matrix)
+
+		select {
+		case <-interrupt:
+			return "", false
+		case <-resized:
+			matrix.ResizeAndClear()
+		case key, open := <-keys:
+			if !open {
+				return "", false
+			}
+			if choice, done, ok := p.handleKey(key); done {
+				return choice, ok
+			}
+		}
+	}
+}
+
+// handleKey applies a keypress. done is set once the picker should close.
+func (p *Picker) handleKey(key rune) (choice string, done, ok bool) {
+	if p.searching {
+		switch key {
+		case util.KeyEnter:
+			p.searching = false
+		case util.KeyEscape:
+			p.searching = false
+			p.query = ""
+			p.filter()
+		case util.KeyBackspace:
+			if p.query != "" {
+				runes := []rune(p.query)
+				p.query = string(runes[:len(runes)-1])
+				p.filter()
+			}
+		case util.KeyUp, util.KeyDown, util.KeyPageUp, util.KeyPageDown:
+			p.move(key)
+		case util.KeyCtrlC:
+			return "", true, false
+		default:
+			if unicode.IsPrint(key) {
+				p.query += string(key)
+				p.filter()
+			}
+		}
+		return "", false, false
+	}
+
+	switch key {
+	case util.KeyEnter:
+		if len(p.filtered) == 0 {
+			return "", false, false
+		}
+		return p.filtered[p.cursor], true, true
+	case 'q', util.KeyEscape, util.KeyCtrlC:
+		return "", true, false
+	case '/':
+		p.searching = true
+	default:
+		if action, bound := p.Bindings[key]; bound {
+			if len(p.filtered) > 0 {
+				action(p.filtered[p.cursor])
+			}
+			return "", false, false
+		}
+		p.move(key)
+	}
+	return "", false, false
+}
+
+func (p *Picker) notifyMove() {
+	if p.OnMove == nil || len(p.filtered) == 0 {
+		return
+	}
+	if item := p.filtered[p.cursor]; item != p.highlighted {
+		p.highlighted = item
+		p.OnMove(item)
+	}
+}
+
+func (p *Picker) move(key rune) {
+	page := 10
+	switch key {
+	case util.KeyUp, 'k':
+		p.cursor--
+	case util.KeyDown, 'j':
+		p.cursor++
+	case util.KeyPageUp, util.KeyLeft, 'h':
+		p.cursor -= page
+	case util.KeyPageDown, util.KeyRight, 'l':
+		p.cursor += page
+	case util.KeyHome, 'g':
+		p.cursor = 0
+	case util.KeyEnd, 'G':
+		p.cursor = len(p.filtered) - 1
+	}
+	if p.cursor >= len(p.filtered) {
+		p.cursor = len(p.filtered) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// filter narrows the items to those matching the query, best match first.
+func (p *Picker) filter() {
+	if p.query == "" {
+		p.filtered = p.Items
+	} else {
+		scores := map[string]int{}
+		p.filtered = nil
+		for _, item := range p.Items {
+			if score, ok := fuzzyScore(p.query, item); ok {
+				scores[item] = score
+				p.filtered = append(p.filtered, item)
+			}
+		}
+		sort.SliceStable(p.filtered, func(i, j int) bool {
+			return scores[p.filtered[i]] > scores[p.filtered[j]]
+		})
+	}
+	p.cursor, p.offset = 0, 0
+}
+
+// fuzzyScore matches pattern as a case-insensitive subsequence of item.
+// Consecutive matches and matches at the start of item score higher.
+func fuzzyScore(pattern, item string) (int, bool) {
+	want := []rune(strings.ToLower(pattern))
+	matched, run, score := 0, 0, 0
+	for i, r := range strings.ToLower(item) {
+		if matched < len(want) && r == want[matched] {
+			matched++
+			run++
+			score += 2 * run
+			if i == 0 {
+				score += 3
+			}
+		} else {
+			run = 0
+		}
+	}
+	return score - len(item)/4, matched == len(want)
+}
+
+func (p *Picker) draw(matrix *DisplayMatrix) {
+	matrix.Clear()
+
+	header := fmt.Sprintf("%s (%d/%d)", p.Title, len(p.filtered), len(p.Items))
+	if p.searching || p.query != "" {
+		header = "/" + p.query
+		if p.searching {
+			header += "▏"
+		}
+	}
+	matrix.AddText(0, 0, header)
+	help := p.Help
+	if help == "" {
+		help = pickerHelp
+	}
+	matrix.AddBottomLeftMessage(help)
+
+	listWidth := 0
+	for _, item := range p.Items {
+		if n := len([]rune(item)) + 3; n > listWidth {
+			listWidth = n
+		}
+	}
+	if listWidth > matrix.Width/3 {
+		listWidth = matrix.Width / 3
+	}
+
+	rows := matrix.Height - 2
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if rows > 0 && p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+	for row := 0; row < rows && p.offset+row < len(p.filtered); row++ {
+		marker := "  "
+		if p.offset+row == p.cursor {
+			marker = "> "
+		}
+		line := []rune(marker + p.filtered[p.offset+row])
+		if len(line) > listWidth {
+			line = line[:listWidth]
+		}
+		matrix.AddText(0, row+1, string(line))
+	}
+
+	if previewWidth := matrix.Width - listWidth - 1; p.Preview != nil && len(p.filtered) > 0 && rows > 0 && previewWidth > 0 {
+		preview := NewDisplayMatrix(previewWidth, rows)
+		p.Preview(preview, p.filtered[p.cursor])
+		matrix.Blit(preview, listWidth+1, 1)
+	}
+
+	matrix.Print()
+}
+
+// PickFont browses fonts with sample rendered in the highlighted one.
+func PickFont(fonts []string, current, sample string) (string, bool) {
+	picker := &Picker{
+		Title: "Fonts",
+		Items: fonts,
+		Preview: func(matrix *DisplayMatrix, font string) {
+			if art.IsNativeFont(font) {
+				matrix.AddFittedAsciiArt(sample, font)
+				return
+			}
+			matrix.AddCenteredAsciiArt(art.GetAsciiArt(sample, font), sample)
+		},
+	}
+	return picker.Run(current)
+}
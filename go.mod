@@ -3,8 +3,10 @@ module github.com/cameroncuttingedge/terminal-timer
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/common-nighthawk/go-figure v0.0.0-20210622060536-734e95fb86be
 	github.com/gen2brain/beeep v0.0.0-20240112042604-c7bb2cd88fea
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/mattn/go-tty v0.0.5
 	github.com/mitchellh/go-homedir v1.1.0
 	golang.org/x/term v0.9.0
@@ -12,7 +14,6 @@ require (
 
 require (
 	github.com/go-toast/toast v0.0.0-20190211030409-01e6764cf0a4 // indirect
-	github.com/godbus/dbus/v5 v5.1.0 // indirect
 	github.com/mattn/go-isatty v0.0.10 // indirect
 	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
 	github.com/tadvi/systray v0.0.0-20190226123456-11a2b8fa57af // indirect
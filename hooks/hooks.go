@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cameroncuttingedge/terminal-timer/util"
+)
+
+// Event is something that happens to the timer that a hook can run on.
+type Event string
+
+const (
+	Start       Event = "start"
+	Warning     Event = "warning"
+	Expire      Event = "expire"
+	Acknowledge Event = "acknowledge"
+	Quit        Event = "quit"
+)
+
+// Events lists every event in the order they are documented.
+var Events = []Event{Start, Warning, Expire, Acknowledge, Quit}
+
+// Commands maps each event to the shell command run on it, set from the config
+// file. Timeout bounds how long a hook may run before it is killed.
+var (
+	Commands = map[Event]string{}
+	Timeout  = 10 * time.Second
+)
+
+// Info describes the timer when an event happens. It is passed to hooks as
+// TIMER_* environment variables.
+type Info struct {
+	Reminder string
+	Duration time.Duration
+	Elapsed  time.Duration
+}
+
+var running sync.WaitGroup
+
+// Run starts the hook for event, if one is configured, without waiting for it
+// to finish.
+func Run(event Event, info Info) {
+	command := Commands[event]
+	if command == "" {
+		return
+	}
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		run(event, command, info)
+	}()
+}
+
+// WaitFor waits up to timeout for running hooks to finish or time out, and
+// reports whether they all did.
+func WaitFor(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func run(event Event, command string, info Info) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	cmd := shell(ctx, command)
+	cmd.Env = append(os.Environ(),
+		"TIMER_EVENT="+string(event),
+		"TIMER_REMINDER="+info.Reminder,
+		"TIMER_DURATION="+seconds(info.Duration),
+		"TIMER_ELAPSED="+seconds(info.Elapsed),
+	)
+	// Output is discarded: capturing it through a pipe would keep Run
+	// waiting on any background children after the timeout kills the shell.
+	err := cmd.Run()
+	// Failures are only logged to the -log file, as on stderr they would
+	// garble the countdown.
+	if err == nil || !util.Logging {
+		return
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		log.Printf("Hook for %s timed out after %v: %s", event, Timeout, command)
+	case errors.As(err, &exitErr):
+		log.Printf("Hook for %s exited with status %d: %s", event, exitErr.ExitCode(), command)
+	default:
+		log.Printf("Error running hook for %s: %v", event, err)
+	}
+}
+
+// shell runs command through the platform shell.
+func shell(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(d.Round(time.Second) / time.Second))
+}
+
+// Describe lists the configured hooks, one per line.
+func Describe() string {
+	var s string
+	for _, event := range Events {
+		if command := Commands[event]; command != "" {
+			s += fmt.Sprintf("%s: %s\n", event, command)
+		}
+	}
+	return s
+}
@@ -6,31 +6,64 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/cameroncuttingedge/terminal-timer/alert"
-	"github.com/cameroncuttingedge/terminal-timer/art"
 	"github.com/cameroncuttingedge/terminal-timer/config"
 	"github.com/cameroncuttingedge/terminal-timer/display"
-	"github.com/cameroncuttingedge/terminal-timer/random"
+	"github.com/cameroncuttingedge/terminal-timer/hooks"
 	"github.com/cameroncuttingedge/terminal-timer/util"
 
 	"github.com/mattn/go-tty"
 )
 
-var userDecision = make(chan string, 1)
+// decision is what the user picked on the end screen.
+type decision struct {
+	quit bool
+	// snooze counts down again for this long instead of the full duration.
+	snooze time.Duration
+}
+
+// snoozeKeys are the number keys that snooze for a set number of minutes.
+var snoozeKeys = map[rune]time.Duration{
+	'1': time.Minute,
+	'5': 5 * time.Minute,
+	'0': 10 * time.Minute,
+}
+
+// resized fires whenever the terminal size changes.
+var resized <-chan struct{}
+
+// countdown is the timer being run, kept for the signal handler to work out
+// how far it had got.
+var countdown struct {
+	sync.Mutex
+	reminder string
+	duration time.Duration
+	started  time.Time
+}
+
+// hookGrace is how long quitting waits for hooks that are still running.
+const hookGrace = time.Second
+
+// input is the terminal keys are read from while the timer runs, and keys
+// delivers them.
+var (
+	input *tty.TTY
+	keys  <-chan rune
+)
 
 // main initializes the application, parses flags, and starts the timer loop.
 func main() {
 
 	util.ParseFlags()
 
-	fmt.Printf(*util.PreviewSoundFlag)
-
-	config.LoadOrCreateConfig()
-
-	random.GenerateTempSoundFileName()
+	if err := config.LoadOrCreateConfig(); err != nil {
+		fmt.Println("Error loading config:", err)
+	}
 
 	config.CheckIfConfigChangesRequested()
 
@@ -40,26 +73,81 @@ func main() {
 
 	setupSignalHandling(true)
 
+	resized = util.WatchResize()
+
 	var directInput string
 	if len(flag.Args()) > 0 {
 		directInput = flag.Arg(0)
 	}
 
+	// A preset stands in for the duration, with -t, -a and -r still winning.
+	reminderMessage := *util.ReminderFlag
+	preset, usePreset := config.UsePreset(directInput)
+	if usePreset {
+		directInput = ""
+		if preset.Reminder != "" && !util.IsFlagSet("r") {
+			reminderMessage = preset.Reminder
+		}
+	}
+
+	if err := config.ApplyOverrides(); err != nil {
+		fmt.Println(err)
+		return
+	}
+
 	totalSeconds, err := util.CalculateTotalSeconds(*util.TimerFlag, *util.AlarmFlag, directInput)
 	if err != nil {
 		fmt.Println("Error parsing timer or alarm flag:", err)
 		return
 	}
+	if usePreset && *util.TimerFlag == "" && *util.AlarmFlag == "" {
+		totalSeconds = int(time.Duration(preset.Duration).Seconds())
+	}
 
-	reminder := util.GetReminderMessage(*util.ReminderFlag)
+	reminder := util.GetReminderMessage(reminderMessage)
+	input, keys = openInput()
 	runTimerLoop(totalSeconds, reminder)
-	defer util.Cleanup(true)
+	input.Close()
+	alert.WaitForWebhooks(alert.WebhookTimeout)
+	util.Cleanup(true)
+	waitForHooks()
+}
+
+// waitForHooks gives hooks still running a moment to finish before the timer
+// exits, and says so if it leaves any behind.
+func waitForHooks() {
+	if !hooks.WaitFor(hookGrace) {
+		fmt.Println("Hooks are still running, leaving them in the background.")
+	}
+}
+
+// openInput reads key presses from the terminal until it is closed.
+func openInput() (*tty.TTY, <-chan rune) {
+	t, err := tty.Open()
+	if err != nil {
+		log.Fatalf("failed to open tty: %v", err)
+	}
+
+	keys := make(chan rune)
+	go func() {
+		defer close(keys)
+		for {
+			r, err := t.ReadRune()
+			if err != nil {
+				return
+			}
+			keys <- r
+		}
+	}()
+	return t, keys
 }
 
 // runTimerLoop runs the main timer loop, displaying time and handling user input.
 func runTimerLoop(totalSeconds int, reminder string) {
 	title := "Timer Completed"
 	soundPath := config.Sound
+	duration := time.Duration(totalSeconds) * time.Second
+	snoozes := 0
 	for {
 		util.HideCursor()
 		util.Render()
@@ -70,82 +158,171 @@ func runTimerLoop(totalSeconds int, reminder string) {
 			return
 		}
 
+		status := ""
+		if snoozes > 0 {
+			status = display.SnoozeCount(snoozes)
+		}
+
 		matrix := display.NewDisplayMatrix(width, height)
-		startTimer(totalSeconds, matrix)
-		display.BufferEndMessage(matrix, reminder, config.Font)
+		started := time.Now()
+		countdown.Lock()
+		countdown.reminder, countdown.duration, countdown.started = reminder, duration, started
+		countdown.Unlock()
+		startTimer(duration, matrix, reminder, status)
+		display.BufferEndMessage(matrix, reminder, config.Font, config.SnoozePeriod, snoozes)
 
 		matrix.Print()
-		alert.EndOfTimer(soundPath, title, reminder)
+		if config.ShowTitle {
+			util.SetTitle("⏰ " + reminder)
+		}
+		expired := time.Now()
+		hooks.Run(hooks.Expire, hooks.Info{Reminder: reminder, Duration: duration, Elapsed: duration})
+		stopAlarm := alert.EndOfTimer(soundPath, alert.Notification{
+			Title:   title,
+			Message: reminder,
+			Urgency: alert.UrgencyNormal,
+			Actions: []alert.Action{
+				{Key: "snooze", Label: "Snooze " + util.FormatShort(config.SnoozePeriod)},
+				{Key: "stop", Label: "Stop"},
+			},
+		})
+		alert.SendWebhooks(alert.NewWebhookPayload(reminder, duration, started))
 
-		if !waitForUserInput(matrix, reminder, "") {
-			break
+		var once sync.Once
+		acknowledge := func() {
+			once.Do(func() {
+				stopAlarm()
+				elapsed := duration + time.Since(expired)
+				hooks.Run(hooks.Acknowledge, hooks.Info{Reminder: reminder, Duration: duration, Elapsed: elapsed})
+			})
 		}
-	}
-}
 
-// waitForUserInput waits for user input to restart or quit the timer.
-func waitForUserInput(matrix *display.DisplayMatrix, reminder, font string) bool {
-	tty, err := tty.Open()
-	if err != nil {
-		log.Fatalf("failed to open tty: %v", err)
+		choice := waitForUserInput(matrix, reminder, snoozes, acknowledge)
+		stopAlarm()
+		switch {
+		case choice.quit:
+			elapsed := duration + time.Since(expired)
+			hooks.Run(hooks.Quit, hooks.Info{Reminder: reminder, Duration: duration, Elapsed: elapsed})
+			return
+		case choice.snooze > 0:
+			snoozes++
+			log.Printf("Snoozed %q for %v (%d so far)", reminder, choice.snooze, snoozes)
+			duration = choice.snooze
+		default:
+			snoozes = 0
+			duration = time.Duration(totalSeconds) * time.Second
+		}
 	}
-	defer tty.Close()
+}
 
-	go func() {
-		for {
-			r, err := tty.ReadRune()
-			if err != nil {
-				fmt.Printf("Error reading rune: %v", err)
-				continue
+// waitForUserInput waits for the user to quit, restart or snooze the timer.
+// Any key acknowledges the alarm, as do the Snooze and Stop buttons on the
+// notification.
+func waitForUserInput(matrix *display.DisplayMatrix, reminder string, snoozes int, acknowledge func()) decision {
+	defer util.ShowCursor()
+	for {
+		select {
+		case r, ok := <-keys:
+			if !ok {
+				return decision{quit: true}
 			}
+			acknowledge()
 			switch r {
-			case 'q', 'r':
-				userDecision <- string(r)
-				return
+			case 'q':
+				return decision{quit: true}
+			case 'r':
+				return decision{}
+			case 's':
+				return decision{snooze: config.SnoozePeriod}
 			}
-		}
-	}()
-
-	for {
-		select {
-		case decision := <-userDecision:
-			util.ShowCursor()
-			return decision == "r"
-		default:
+			if snooze, ok := snoozeKeys[r]; ok {
+				return decision{snooze: snooze}
+			}
+		case action := <-alert.NotificationActions():
+			acknowledge()
+			if action == "snooze" {
+				return decision{snooze: config.SnoozePeriod}
+			}
+		case <-resized:
 			matrix.ResizeAndClear()
-			display.BufferEndMessage(matrix, reminder, config.Font)
+			display.BufferEndMessage(matrix, reminder, config.Font, config.SnoozePeriod, snoozes)
 			matrix.Print()
-			time.Sleep(100 * time.Millisecond)
 		}
 	}
 }
 
-// startTimer counts down the timer and updates the display.
-func startTimer(totalSeconds int, matrix *display.DisplayMatrix) {
-	endTime := time.Now().Add(time.Duration(totalSeconds) * time.Second)
+// startTimer counts down the timer and updates the display. status, when set,
+// is shown under the countdown. Warnings shorter than the duration fire as the
+// countdown passes them.
+func startTimer(duration time.Duration, matrix *display.DisplayMatrix, reminder, status string) {
+	endTime := time.Now().Add(duration)
+
+	var warnings []time.Duration
+	for _, warning := range alert.Warnings {
+		if warning < duration {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	info := func() hooks.Info {
+		return hooks.Info{Reminder: reminder, Duration: duration, Elapsed: duration - time.Until(endTime)}
+	}
+	hooks.Run(hooks.Start, info())
 
 	// Call the update function once before entering the loop to display the first tick
-	updateTimerDisplay(endTime, matrix)
+	updateTimerDisplay(time.Until(endTime), matrix, status, timerTitle(time.Until(endTime), reminder))
 
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		if time.Now().After(endTime) {
-			break
+	for {
+		select {
+		case <-ticker.C:
+			if time.Now().After(endTime) {
+				return
+			}
+			if len(warnings) > 0 && time.Until(endTime) <= warnings[0] {
+				alert.Warn(warnings[0], reminder)
+				hooks.Run(hooks.Warning, info())
+				go util.Flash()
+				for len(warnings) > 0 && time.Until(endTime) <= warnings[0] {
+					warnings = warnings[1:]
+				}
+			}
+		case <-resized:
+			matrix.ResizeAndClear()
+		case _, ok := <-keys:
+			// Keys pressed during the countdown are dropped so they don't
+			// acknowledge the alarm before it sounds.
+			if !ok {
+				keys = nil
+			}
+			continue
 		}
-		updateTimerDisplay(endTime, matrix)
+		updateTimerDisplay(time.Until(endTime), matrix, status, timerTitle(time.Until(endTime), reminder))
 	}
 }
 
-// updateTimerDisplay handles updating and printing the timer to the display matrix
-func updateTimerDisplay(endTime time.Time, matrix *display.DisplayMatrix) {
-	remaining := time.Until(endTime)
-	timerRemaining := fmt.Sprintf("%02d:%02d:%02d", int(remaining.Hours()), int(remaining.Minutes())%60, int(remaining.Seconds())%60)
-	asciiArt := art.GetAsciiArt(timerRemaining, config.Font)
-	matrix.AddCenteredAsciiArt(asciiArt, timerRemaining)
+// updateTimerDisplay handles updating and printing the timer to the display
+// matrix, and to the terminal title when that is turned on.
+func updateTimerDisplay(remaining time.Duration, matrix *display.DisplayMatrix, status, title string) {
+	timerRemaining := util.FormatRemaining(remaining)
+	matrix.Clear()
+	matrix.AddFittedAsciiArt(timerRemaining, config.Font)
+	if status != "" {
+		matrix.AddBottomLeftMessage(status)
+	}
 	matrix.Print()
-	matrix.ResizeAndClear()
+	if config.ShowTitle {
+		util.SetTitle(title)
+	}
+}
+
+// timerTitle is the terminal title for the countdown, such as
+// "⏳ 12:34 – Standup". Hours are left out when there are none.
+func timerTitle(remaining time.Duration, reminder string) string {
+	left := strings.TrimPrefix(util.FormatRemaining(remaining), "00:")
+	return fmt.Sprintf("⏳ %s – %s", left, reminder)
 }
 
 // setupSignalHandling configures handling for SIGINT and SIGTERM.
@@ -155,8 +332,19 @@ func setupSignalHandling(clearScreen bool) {
 
 	go func() {
 		<-c
+		countdown.Lock()
+		info := hooks.Info{Reminder: countdown.reminder, Duration: countdown.duration}
+		if !countdown.started.IsZero() {
+			info.Elapsed = time.Since(countdown.started)
+		}
+		countdown.Unlock()
+		hooks.Run(hooks.Quit, info)
+		if input != nil {
+			input.Close()
+		}
 		util.Cleanup(clearScreen)
 		fmt.Println("\nReceived Ctrl+C, exiting...")
+		waitForHooks()
 		os.Exit(0)
 	}()
 }
@@ -1,23 +1,16 @@
 package util
 
-import (
-	"log"
-	"os"
-
-	"github.com/cameroncuttingedge/terminal-timer/random"
-)
-
 // cleanup performs application cleanup tasks.
 func Cleanup(clearScreen bool) {
+	RestoreTitle()
 	ShowCursor()
 	if clearScreen {
 		Clear()
 	}
 	Render()
-	if random.TempFileName != "" {
-		err := os.Remove(random.TempFileName)
-		if err != nil && !os.IsNotExist(err) {
-			log.Printf("Failed to delete temporary file %s: %v\n", random.TempFileName, err)
-		}
-	}
+
+	// Files still held by a player that is being killed on exit.
+	tempDir.mu.Lock()
+	removeTempDir()
+	tempDir.mu.Unlock()
 }
@@ -11,20 +11,37 @@ var (
 	ReminderFlag = flag.String("r", "Time is Up!", "Reminder message")
 
 	// Font options
-	SetFontFlag     = flag.String("f", "", "Set a new font")
-	PreviewFontFlag = flag.String("pf", "", "Preview the font")
+	FontFlag        = flag.String("font", "", "Font for this run only; use 'config set font' to change the default")
+	PreviewFontFlag = flag.Bool("pf", false, "Browse the fonts with a live preview and save the chosen one")
 	ListValidFonts  = flag.Bool("lf", false, "List all valid fonts")
 
 	// Sound options
-	PreviewSoundFlag = flag.String("ps", "", "Preview the sound")
+	PreviewSoundFlag = flag.Bool("ps", false, "Browse the sounds, playing each one, and save the chosen one")
 	ListValidSounds  = flag.Bool("ls", false, "List all default sounds")
-	SetSoundFlag     = flag.String("s", "", "Set a new sound")
+	SoundFlag        = flag.String("sound", "", "Sound for this run only; use 'config set sound' to change the default")
+	DoctorFlag       = flag.Bool("doctor", false, "Report which audio backends are usable")
 
 	// Logging options
 	EnableLogging     = flag.Bool("log", false, "Enable logging to a file")
 	ShowCurrentConfig = flag.Bool("c", false, "Show current sound and font config")
 )
 
+func init() {
+	flag.StringVar(FontFlag, "f", "", "Shorthand for -font")
+	flag.StringVar(SoundFlag, "s", "", "Shorthand for -sound")
+}
+
 func ParseFlags() {
 	flag.Parse()
 }
+
+// IsFlagSet reports whether the named flag was given on the command line.
+func IsFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
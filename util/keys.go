@@ -0,0 +1,97 @@
+package util
+
+import (
+	"github.com/mattn/go-tty"
+)
+
+// Keys that arrive as escape sequences are reported as negative runes so they
+// can never clash with typed characters.
+const (
+	KeyUp rune = -1 - iota
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyEscape
+)
+
+const (
+	KeyEnter     rune = '\r'
+	KeyBackspace rune = 0x7f
+	KeyCtrlC     rune = 0x03
+)
+
+// ReadKey reads a single keypress from t, decoding the common ANSI escape
+// sequences for cursor and paging keys.
+func ReadKey(t *tty.TTY) (rune, error) {
+	r, err := t.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch r {
+	case '\n':
+		return KeyEnter, nil
+	case '\b':
+		return KeyBackspace, nil
+	case 0x1b:
+	default:
+		return r, nil
+	}
+
+	// A lone escape has nothing queued behind it.
+	if !t.Buffered() {
+		return KeyEscape, nil
+	}
+	r, err = t.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if r != '[' && r != 'O' {
+		return KeyEscape, nil
+	}
+
+	r, err = t.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch r {
+	case 'A':
+		return KeyUp, nil
+	case 'B':
+		return KeyDown, nil
+	case 'C':
+		return KeyRight, nil
+	case 'D':
+		return KeyLeft, nil
+	case 'H':
+		return KeyHome, nil
+	case 'F':
+		return KeyEnd, nil
+	}
+
+	// Sequences such as ESC [ 5 ~ carry a number terminated by a tilde.
+	code := 0
+	for r >= '0' && r <= '9' {
+		code = code*10 + int(r-'0')
+		if r, err = t.ReadRune(); err != nil {
+			return 0, err
+		}
+	}
+	if r != '~' {
+		return KeyEscape, nil
+	}
+	switch code {
+	case 1, 7:
+		return KeyHome, nil
+	case 4, 8:
+		return KeyEnd, nil
+	case 5:
+		return KeyPageUp, nil
+	case 6:
+		return KeyPageDown, nil
+	}
+	return KeyEscape, nil
+}
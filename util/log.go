@@ -5,6 +5,10 @@ import (
 	"os"
 )
 
+// Logging reports whether SetupLogger has sent log output to a file. Until
+// then it goes to stderr, where it would garble the countdown.
+var Logging bool
+
 func SetupLogger() {
 	logFile, err := os.OpenFile("application.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -12,4 +16,5 @@ func SetupLogger() {
 	}
 
 	log.SetOutput(logFile)
+	Logging = true
 }
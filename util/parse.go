@@ -70,3 +70,21 @@ func GetReminderMessage(reminderFlag string) string {
 	}
 	return reminderFlag
 }
+
+// FormatRemaining renders a duration the way the countdown shows it, hh:mm:ss.
+func FormatRemaining(remaining time.Duration) string {
+	return fmt.Sprintf("%02d:%02d:%02d", int(remaining.Hours()), int(remaining.Minutes())%60, int(remaining.Seconds())%60)
+}
+
+// FormatShort renders a duration without trailing zero units, such as 5m or
+// 1h30m.
+func FormatShort(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
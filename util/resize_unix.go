@@ -0,0 +1,28 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WatchResize returns a channel that receives a value whenever the terminal
+// is resized. Bursts of SIGWINCH are coalesced so a slow consumer only ever
+// sees one pending notification.
+func WatchResize() <-chan struct{} {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+
+	resized := make(chan struct{}, 1)
+	go func() {
+		for range sig {
+			select {
+			case resized <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return resized
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package util
+
+import "time"
+
+// WatchResize returns a channel that receives a value whenever the terminal
+// is resized. The Windows console has no SIGWINCH, so the size is polled.
+func WatchResize() <-chan struct{} {
+	resized := make(chan struct{}, 1)
+	go func() {
+		width, height, _ := GetSize()
+		for range time.Tick(250 * time.Millisecond) {
+			w, h, err := GetSize()
+			if err != nil || (w == width && h == height) {
+				continue
+			}
+			width, height = w, h
+			select {
+			case resized <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return resized
+}
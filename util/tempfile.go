@@ -0,0 +1,65 @@
+package util
+
+import (
+	"log"
+	"os"
+	"sync"
+)
+
+// tempDir is a private directory for temporary files, created on demand and
+// removed once the last file in it is released.
+var tempDir struct {
+	mu    sync.Mutex
+	path  string
+	files int
+}
+
+// CreateTemp creates a new file named after pattern, as in os.CreateTemp, in
+// the timer's private temporary directory. release removes the file, and the
+// directory with it if no other files are left; it is safe to call more than
+// once.
+func CreateTemp(pattern string) (file *os.File, release func(), err error) {
+	tempDir.mu.Lock()
+	defer tempDir.mu.Unlock()
+
+	if tempDir.path == "" {
+		path, err := os.MkdirTemp("", "terminal-timer-")
+		if err != nil {
+			return nil, nil, err
+		}
+		tempDir.path = path
+	}
+	file, err = os.CreateTemp(tempDir.path, pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	tempDir.files++
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			if err := os.Remove(file.Name()); err != nil && !os.IsNotExist(err) {
+				log.Printf("Error removing temporary file '%s': %v", file.Name(), err)
+			}
+			tempDir.mu.Lock()
+			defer tempDir.mu.Unlock()
+			tempDir.files--
+			if tempDir.files == 0 {
+				removeTempDir()
+			}
+		})
+	}
+	return file, release, nil
+}
+
+// removeTempDir deletes the private directory and anything left in it. The
+// caller holds tempDir.mu.
+func removeTempDir() {
+	if tempDir.path == "" {
+		return
+	}
+	if err := os.RemoveAll(tempDir.path); err != nil {
+		log.Printf("Error removing temporary directory '%s': %v", tempDir.path, err)
+	}
+	tempDir.path = ""
+}
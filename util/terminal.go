@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -16,6 +17,13 @@ func Clear() {
 	fmt.Fprint(os.Stdout, clearCode)
 }
 
+// Flash briefly switches the terminal to reverse video.
+func Flash() {
+	fmt.Fprint(os.Stdout, "\033[?5h")
+	time.Sleep(150 * time.Millisecond)
+	fmt.Fprint(os.Stdout, "\033[?5l")
+}
+
 func CmdExists(cmd string) bool {
 	_, err := exec.LookPath(cmd)
 	return err == nil
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// title remembers whether the terminal title was changed and, inside tmux,
+// what the pane title was beforehand. mu guards it, as the countdown sets the
+// title while a signal can restore it.
+var title struct {
+	mu        sync.Mutex
+	changed   bool
+	tmuxTitle string
+}
+
+// SetTitle sets the terminal window or tab title, which inside tmux is the
+// pane title. The first call saves the current title for RestoreTitle.
+func SetTitle(s string) {
+	title.mu.Lock()
+	defer title.mu.Unlock()
+	if !title.changed {
+		title.changed = true
+		if os.Getenv("TMUX") != "" {
+			out, err := exec.Command("tmux", "display-message", "-p", "#{pane_title}").Output()
+			if err == nil {
+				title.tmuxTitle = strings.TrimRight(string(out), "\n")
+			}
+		} else {
+			// Push the title onto the terminal's title stack.
+			fmt.Fprint(os.Stdout, "\033[22;0t")
+		}
+	}
+	fmt.Fprintf(os.Stdout, "\033]2;%s\007", titleText(s))
+}
+
+// RestoreTitle puts back the title from before the first SetTitle.
+func RestoreTitle() {
+	title.mu.Lock()
+	defer title.mu.Unlock()
+	if !title.changed {
+		return
+	}
+	title.changed = false
+	if os.Getenv("TMUX") != "" {
+		fmt.Fprintf(os.Stdout, "\033]2;%s\007", titleText(title.tmuxTitle))
+		return
+	}
+	fmt.Fprint(os.Stdout, "\033[23;0t")
+}
+
+// titleText drops control characters that would end the sequence early.
+func titleText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}